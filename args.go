@@ -0,0 +1,217 @@
+package subcmd
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// An Arg describes one of a command's positional arguments.
+type Arg struct {
+	Name    string   // the argument's name in usage messages (e.g., "FORMAT")
+	Choices []string // the allowed values; if empty, any value is allowed
+}
+
+// An ArgsValidator checks the number of a command's positional arguments,
+// returning an error that describes the problem if it is wrong.
+type ArgsValidator func(args []string) error
+
+// NoArgs is an ArgsValidator that rejects any positional arguments.
+func NoArgs(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("expects no arguments, got %d", len(args))
+	}
+	return nil
+}
+
+// ExactArgs returns an ArgsValidator that requires exactly n arguments.
+func ExactArgs(n int) ArgsValidator {
+	return RangeArgs(n, n)
+}
+
+// MinArgs returns an ArgsValidator that requires at least n arguments.
+func MinArgs(n int) ArgsValidator {
+	return RangeArgs(n, -1)
+}
+
+// MaxArgs returns an ArgsValidator that permits at most n arguments.
+func MaxArgs(n int) ArgsValidator {
+	return RangeArgs(0, n)
+}
+
+// RangeArgs returns an ArgsValidator that requires at least min arguments
+// and at most max arguments. If max is negative, there is no maximum.
+func RangeArgs(min, max int) ArgsValidator {
+	return func(args []string) error {
+		n := len(args)
+		if n >= min && (max < 0 || n <= max) {
+			return nil
+		}
+		var want string
+		switch {
+		case min == max:
+			want = pluralArgs(min)
+		case max < 0:
+			want = "at least " + pluralArgs(min)
+		case min == 0:
+			want = "at most " + pluralArgs(max)
+		default:
+			want = fmt.Sprintf("%d to %d arguments", min, max)
+		}
+		return fmt.Errorf("expects %s, got %d", want, n)
+	}
+}
+
+func pluralArgs(n int) string {
+	if n == 1 {
+		return "1 argument"
+	}
+	return fmt.Sprintf("%d arguments", n)
+}
+
+// argsUsage returns the synopsis of cmd's arguments: ArgsUsage if it is set,
+// or else the names of cmd.Args.
+func (cmd *Command) argsUsage() string {
+	if cmd.ArgsUsage != "" || len(cmd.Args) == 0 {
+		return cmd.ArgsUsage
+	}
+	names := make([]string, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		names[i] = arg.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// positionalArgs returns the arguments in args that remain after the flags
+// defined by cmd.Flags (if any) are removed. It doesn't change the values of
+// cmd.Flags. If args cannot be parsed, positionalArgs returns false.
+func (cmd *Command) positionalArgs(args []string) ([]string, bool) {
+	if cmd.Flags == nil {
+		return args, true
+	}
+	// Parse into a stand-in for cmd.Flags that only records whether each
+	// flag takes a value.
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Usage = func() {}
+	cmd.Flags.VisitAll(func(f *flag.Flag) {
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			fs.Bool(f.Name, false, "")
+		} else {
+			fs.String(f.Name, "", "")
+		}
+	})
+	if err := fs.Parse(args); err != nil {
+		return nil, false
+	}
+	return fs.Args(), true
+}
+
+// parseCommandFlags parses args, the arguments of cmd, according to
+// cmd.Flags, setting the values of its flags, and returns the positional
+// arguments that remain. It returns ErrHelp if args includes -h or -help and
+// the command doesn't define those flags.
+func (r *Runner) parseCommandFlags(cmd *Command, args []string) ([]string, error) {
+	if cmd.Flags == nil {
+		return args, nil
+	}
+	// Parse with a copy of cmd.Flags to control how errors are handled
+	// regardless of cmd.Flags's error-handling behavior.
+	fs := flag.NewFlagSet(r.name+" "+cmd.Name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Usage = func() {}
+	cmd.Flags.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil, ErrHelp
+		}
+		// The flag package's messages include the offending argument.
+		return nil, commandUsageError{cmd, r.errorf("%s: %s", cmd.Name, sanitize(err.Error()))}
+	}
+	if missing := missingFlags(fs, cmd.RequiredFlags); len(missing) > 0 {
+		format := "%s: missing required flag %s"
+		if len(missing) > 1 {
+			format = "%s: missing required flags %s"
+		}
+		return nil, commandUsageError{cmd, r.errorf(format, cmd.Name, strings.Join(missing, ", "))}
+	}
+	return fs.Args(), nil
+}
+
+// missingFlags returns the flags named by required that were not set when
+// fs was parsed, each with its leading "-".
+func missingFlags(fs *flag.FlagSet, required []string) []string {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	var missing []string
+	for _, name := range required {
+		if !set[name] {
+			missing = append(missing, "-"+name)
+		}
+	}
+	return missing
+}
+
+// checkArgs reports an error if args, the positional arguments of cmd, are
+// rejected by cmd.NArgs or if any of them is not one of the choices
+// permitted by cmd.Args.
+func (r *Runner) checkArgs(cmd *Command, args []string) error {
+	if cmd.NArgs != nil {
+		if err := cmd.NArgs(args); err != nil {
+			return commandUsageError{cmd, r.errorf("%s: %s", cmd.Name, err)}
+		}
+	}
+	for i, arg := range cmd.Args {
+		if i >= len(args) || len(arg.Choices) == 0 {
+			continue
+		}
+		if !contains(arg.Choices, args[i]) {
+			err := r.errorf("%s: invalid %s %s (must be one of %s)",
+				cmd.Name, arg.Name, quoteArg(args[i]), strings.Join(arg.Choices, ", "))
+			return commandUsageError{cmd, err}
+		}
+	}
+	return nil
+}
+
+// A commandUsageError is an error in the use of a command, such as an
+// unknown flag, which is reported along with the command's usage.
+type commandUsageError struct {
+	cmd *Command
+	err error
+}
+
+func (e commandUsageError) Error() string { return e.err.Error() }
+func (e commandUsageError) Unwrap() error { return e.err }
+
+// completeArgs returns the completions for args, the arguments of cmd,
+// according to the choices permitted by cmd.Args.
+func (cmd *Command) completeArgs(args []string) []string {
+	word := args[len(args)-1]
+	if strings.HasPrefix(word, "-") {
+		return nil
+	}
+	args, ok := cmd.positionalArgs(args)
+	if !ok || len(args) == 0 || len(args) > len(cmd.Args) {
+		return nil
+	}
+	var completions []string
+	for _, choice := range cmd.Args[len(args)-1].Choices {
+		if strings.HasPrefix(choice, word) {
+			completions = append(completions, choice)
+		}
+	}
+	return completions
+}
+
+func contains(ss []string, s string) bool {
+	for _, s1 := range ss {
+		if s1 == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package subcmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"strings"
+)
+
+// RunBatch runs the commands read from in, one command line per line, in
+// turn, as RunContext would run each of them. The lines are split into
+// arguments as by a shell: arguments are separated by spaces or tabs, may
+// be quoted with single or double quotes, and may contain characters
+// escaped with a backslash. A "#" at the beginning of an argument begins a
+// comment, which runs to the end of the line, and blank lines are ignored.
+// This lets a script run many commands without starting the program for
+// each one:
+//
+//	# setup.txt
+//	user add alice
+//	user add --admin bob
+//	group add "site reliability" alice bob
+//
+//	prog batch < setup.txt
+//
+// (RunBatch may implement a command of the program, as above, or be called
+// by the program in place of Run.) Commands that read their standard input
+// consume the following lines of in, so a batch is best supplied by other
+// means than standard input if any of its commands read it.
+//
+// Like a chain of commands (see ChainSeparator), RunBatch stops at the first
+// command that fails, or at a line that cannot be split into arguments,
+// unless r.ChainKeepGoing is set. The failure is handled according to the
+// runner's error-handling behavior: with ContinueOnError, RunBatch returns
+// the first error.
+//
+// Before each line runs, the global flags and the flags of all the commands
+// are reset to their defaults, so that the flags given on one line don't
+// apply to the next.
+func (r *Runner) RunBatch(ctx context.Context, in io.Reader) error {
+	done := r.keepGoing()
+	defer func(argv []string) { r.argv = argv }(r.argv)
+	var first error
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		args, err := splitCommandLine(scanner.Text())
+		switch {
+		case err != nil:
+			err = r.batchError(r.errorf("line %d: %s", line, err))
+		case len(args) == 0:
+			continue
+		default:
+			resetFlags(r.Flags)
+			resetCommandFlags(r.cmds)
+			r.argv = args
+			err = r.RunContext(ctx, args)
+		}
+		if err == nil {
+			continue
+		}
+		if !r.ChainKeepGoing {
+			return err
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	if err := scanner.Err(); err != nil && first == nil {
+		first = r.batchError(r.errorf("cannot read commands: %s", err))
+	}
+	done()
+	return first
+}
+
+// batchError handles err, a failure to read a command line for RunBatch,
+// according to the runner's error-handling behavior. Unlike a usage error, it
+// isn't followed by the usage message.
+func (r *Runner) batchError(err error) error {
+	switch r.errorHandling {
+	case flag.ContinueOnError:
+		return err
+	case flag.PanicOnError:
+		panic(err)
+	}
+	r.printError(err, 2, r.name)
+	if r.chain.fail(2) {
+		return err
+	}
+	r.Exit(2)
+	panic("unreached")
+}
+
+// splitCommandLine splits line into arguments as described for RunBatch.
+func splitCommandLine(line string) ([]string, error) {
+	var (
+		args    []string
+		arg     strings.Builder
+		inArg   bool // arg has begun, even if it is empty (as in "")
+		quote   rune // the quote character of the quoted string we're in, if any
+		escaped bool // the previous character was a backslash
+	)
+	for _, c := range line {
+		switch {
+		case escaped:
+			if quote == '"' && c != '"' && c != '\\' {
+				// Within double quotes, a backslash only escapes a
+				// double quote or a backslash.
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case c == '#' && !inArg:
+			return args, nil
+		default:
+			arg.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quoted string")
+	}
+	if escaped {
+		return nil, errors.New("backslash at end of line")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,81 @@
+package subcmd
+
+import (
+	"context"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	for _, tt := range []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"   \t ", nil},
+		{"add alice", []string{"add", "alice"}},
+		{"  add \t alice  ", []string{"add", "alice"}},
+		{`group add 'site reliability' bob`, []string{"group", "add", "site reliability", "bob"}},
+		{`echo "a b" "" ''`, []string{"echo", "a b", "", ""}},
+		{`echo "say \"hi\"" "a\\b" "\n"`, []string{"echo", `say "hi"`, `a\b`, `\n`}},
+		{`echo 'it''s' 'a\b'`, []string{"echo", "its", `a\b`}},
+		{`echo a\ b \'c\'`, []string{"echo", "a b", "'c'"}},
+		{`echo ab"c d"e`, []string{"echo", "abc de"}},
+		{"# a comment", nil},
+		{"echo a # a comment", []string{"echo", "a"}},
+		{"echo a#b '#c'", []string{"echo", "a#b", "#c"}},
+	} {
+		got, err := splitCommandLine(tt.line)
+		if err != nil {
+			t.Errorf("splitCommandLine(%q): %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q; want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSplitCommandLineError(t *testing.T) {
+	for _, line := range []string{
+		`echo "a`,
+		`echo 'a`,
+		`echo a\`,
+	} {
+		if got, err := splitCommandLine(line); err == nil {
+			t.Errorf("splitCommandLine(%q) = %q; want error", line, got)
+		}
+	}
+}
+
+func TestRunBatchResetsFlags(t *testing.T) {
+	var got []string
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	admin := fs.Bool("admin", false, "")
+	cmds := []Command{{
+		Name:  "add",
+		Flags: fs,
+		Do: func(args []string) {
+			if *admin {
+				args = append(args, "(admin)")
+			}
+			got = append(got, strings.Join(args, " "))
+		},
+	}}
+	r := New("prog", cmds, flag.ContinueOnError)
+	verbose := r.Flags.Bool("v", false, "")
+	var verboseLines []bool
+	r.Before = func(string, []string) { verboseLines = append(verboseLines, *verbose) }
+	in := strings.NewReader("-v add --admin bob\nadd alice\n")
+	if err := r.RunBatch(context.Background(), in); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"bob (admin)", "alice"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if want := []bool{true, false}; !reflect.DeepEqual(verboseLines, want) {
+		t.Errorf("-v on each line: got %v; want %v", verboseLines, want)
+	}
+}
@@ -0,0 +1,29 @@
+package subcmd
+
+import "io"
+
+// brokenPipeExitCode is the conventional exit status of a program that is
+// killed by SIGPIPE (128+13).
+const brokenPipeExitCode = 141
+
+// IsBrokenPipe reports whether err results from writing to a pipe whose
+// reader has gone away, as happens when a program's output is piped into
+// head(1).
+func IsBrokenPipe(err error) bool {
+	return err != nil && isBrokenPipe(err)
+}
+
+// exitOnBrokenPipe is an io.Writer that quietly exits the program if a
+// write fails because of a broken pipe.
+type exitOnBrokenPipe struct {
+	w    io.Writer
+	exit func(code int)
+}
+
+func (w exitOnBrokenPipe) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	if IsBrokenPipe(err) {
+		w.exit(brokenPipeExitCode)
+	}
+	return n, err
+}
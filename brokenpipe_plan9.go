@@ -0,0 +1,4 @@
+package subcmd
+
+// Plan 9 reports broken pipes as notes rather than as errors from write.
+func isBrokenPipe(err error) bool { return false }
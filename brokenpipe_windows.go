@@ -0,0 +1,16 @@
+package subcmd
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorNoData is ERROR_NO_DATA, which Windows reports for writes to a pipe
+// that is being closed.
+const errorNoData syscall.Errno = 232
+
+func isBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ERROR_BROKEN_PIPE) ||
+		errors.Is(err, errorNoData)
+}
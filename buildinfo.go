@@ -0,0 +1,104 @@
+package subcmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+// versionCommand is the name of the command enabled by
+// Runner.VersionCommand.
+const versionCommand = "version"
+
+// buildInfo describes the program's version and build.
+type buildInfo struct {
+	Version   string `json:"version,omitempty"`
+	Module    string `json:"module,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+// readBuildInfo gathers the details of the program's build from
+// debug.ReadBuildInfo, which has them unless the program was built without
+// module support.
+func (r *Runner) readBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:   r.Version,
+		GoVersion: runtime.Version(),
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Module = bi.Main.Path
+	if info.Version == "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+	if bi.GoVersion != "" {
+		info.GoVersion = bi.GoVersion
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.time":
+			info.Time = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
+
+func (r *Runner) versionCommand() Command {
+	fs := flag.NewFlagSet(r.name+" "+versionCommand, flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the version and build details as JSON")
+	return Command{
+		Name:        versionCommand,
+		Description: "print the program's version",
+		Flags:       fs,
+		Do:          func([]string) { r.printVersion(*asJSON) },
+	}
+}
+
+// printVersion prints the program's version and build details to r's
+// standard output, as JSON if asJSON is set.
+func (r *Runner) printVersion(asJSON bool) {
+	info := r.readBuildInfo()
+	if asJSON {
+		enc := json.NewEncoder(r.Stdout())
+		enc.SetIndent("", "  ")
+		enc.Encode(info)
+		return
+	}
+	writeBuildInfo(r.Stdout(), r.programName(), info)
+}
+
+func writeBuildInfo(w io.Writer, name string, info buildInfo) {
+	version := info.Version
+	if version == "" {
+		version = "(unknown)"
+	}
+	fmt.Fprintf(w, "%s version %s\n", name, version)
+	var rows [][]string
+	if info.Module != "" {
+		rows = append(rows, []string{"module", info.Module})
+	}
+	if info.Revision != "" {
+		revision := info.Revision
+		if info.Modified {
+			revision += " (modified)"
+		}
+		rows = append(rows, []string{"revision", revision})
+	}
+	if info.Time != "" {
+		rows = append(rows, []string{"time", info.Time})
+	}
+	rows = append(rows, []string{"go", info.GoVersion})
+	writeColumns(w, rows)
+}
@@ -0,0 +1,111 @@
+package subcmd
+
+import (
+	"context"
+	"flag"
+)
+
+// splitChain splits args, the arguments following the global flags, at
+// each occurrence of r.ChainSeparator.
+func (r *Runner) splitChain(args []string) [][]string {
+	var chain [][]string
+	start := 0
+	for i, arg := range args {
+		if arg == r.ChainSeparator {
+			chain = append(chain, args[start:i])
+			start = i + 1
+		}
+	}
+	return append(chain, args[start:])
+}
+
+// runChain runs each of the command lines in chain in turn. Each is
+// preceded by flagArgs, the global flags, should it be re-executed in a
+// child process (see Isolate).
+func (r *Runner) runChain(ctx context.Context, flagArgs []string, chain [][]string, opts runOptions) error {
+	done := r.keepGoing()
+	defer func(argv []string) { r.argv = argv }(r.argv)
+	var first error
+	for i, args := range chain {
+		if i > 0 {
+			// Only the first command follows a "--". The global flags
+			// apply to the whole chain, but the flags of one command
+			// don't apply to the next.
+			opts.literal = false
+			resetCommandFlags(r.cmds)
+		}
+		r.argv = append(flagArgs[:len(flagArgs):len(flagArgs)], args...)
+		err := r.dispatch(ctx, args, opts)
+		if err == nil {
+			continue
+		}
+		if !r.ChainKeepGoing {
+			return err
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	done()
+	return first
+}
+
+// keepGoing arranges for the failures of the commands that follow to be
+// recorded, rather than exit the program, if r.ChainKeepGoing is set and
+// the error-handling behavior is ExitOnError. It returns a function to call
+// once the commands have run, which exits the program with the status of
+// the first failure, if there was one. A chain within a chain (such as
+// one on a line read by RunBatch) is part of the outer chain.
+func (r *Runner) keepGoing() (done func()) {
+	if !r.ChainKeepGoing || r.errorHandling != flag.ExitOnError || r.chain != nil {
+		return func() {}
+	}
+	c := new(chainState)
+	r.chain = c
+	return func() {
+		r.chain = nil
+		if c.failed {
+			r.Exit(c.code)
+		}
+	}
+}
+
+// A chainState records the first failure in a chain of commands run with
+// ChainKeepGoing under ExitOnError, which exits only once the whole chain has
+// run.
+type chainState struct {
+	failed bool
+	code   int // the exit status of the first failure
+}
+
+// fail records a failure with the exit status code and reports whether the
+// program should carry on rather than exit. It is safe to call on a nil
+// *chainState, which never carries on.
+func (c *chainState) fail(code int) bool {
+	if c == nil {
+		return false
+	}
+	if !c.failed {
+		c.failed, c.code = true, code
+	}
+	return true
+}
+
+// resetFlags sets each flag in fs, which may be nil, to its default value.
+func resetFlags(fs *flag.FlagSet) {
+	if fs == nil {
+		return
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		f.Value.Set(f.DefValue)
+	})
+}
+
+// resetCommandFlags sets the Flags of cmds, and of their Subcommands, to
+// their default values.
+func resetCommandFlags(cmds []Command) {
+	for i := range cmds {
+		resetFlags(cmds[i].Flags)
+		resetCommandFlags(cmds[i].Subcommands)
+	}
+}
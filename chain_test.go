@@ -0,0 +1,32 @@
+package subcmd
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChainResetsCommandFlags(t *testing.T) {
+	var got []string
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	admin := fs.Bool("admin", false, "")
+	cmds := []Command{{
+		Name:  "add",
+		Flags: fs,
+		Do: func(args []string) {
+			if *admin {
+				args = append(args, "(admin)")
+			}
+			got = append(got, strings.Join(args, " "))
+		},
+	}}
+	r := New("prog", cmds, flag.ContinueOnError)
+	r.ChainSeparator = ";"
+	if err := r.Run([]string{"add", "-admin", "x", ";", "add", "y"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"x (admin)", "y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,222 @@
+// Command subcmd-new creates a new command-line program that is organized
+// around a subcmd.Runner.
+//
+// Usage:
+//
+//	subcmd-new [-module path] DIR [COMMAND...]
+//
+// subcmd-new writes a main package to DIR, which must not already contain a
+// go.mod file, along with a package under DIR/commands for each COMMAND
+// (or for a single command called "hello", if none are given). Each command
+// package has a test and a completion function to fill in. After generating
+// the project, run 'go mod tidy' in DIR to add the requirement on subcmd.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("subcmd-new: ")
+	modulePath := flag.String("module", "", "module `path` of the new program (default: the base name of DIR)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage:\n\n  subcmd-new [flags] DIR [COMMAND...]\n\nFlags:\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := flag.Arg(0)
+	p := project{
+		Module: *modulePath,
+		Name:   filepath.Base(dir),
+	}
+	if p.Module == "" {
+		p.Module = p.Name
+	}
+	names := flag.Args()[1:]
+	if len(names) == 0 {
+		names = []string{"hello"}
+	}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		c := command{Name: name, Package: packageName(name)}
+		if seen[c.Package] {
+			log.Fatalf("commands would share the package name %q", c.Package)
+		}
+		seen[c.Package] = true
+		p.Commands = append(p.Commands, c)
+	}
+	if err := p.write(dir); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Created %s. Run 'go mod tidy' in %s to finish setting it up.\n", p.Name, dir)
+}
+
+type project struct {
+	Module   string
+	Name     string
+	Commands []command
+}
+
+type command struct {
+	Name    string // the command's name on the command line
+	Package string // the name of the command's package
+}
+
+func (p *project) write(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+		return fmt.Errorf("%s already contains a go.mod file", dir)
+	}
+	files := map[string]string{
+		"go.mod":  render(goModTemplate, p),
+		"main.go": render(mainTemplate, p),
+	}
+	for _, c := range p.Commands {
+		data := struct {
+			command
+			Program string
+		}{c, p.Name}
+		base := filepath.Join("commands", c.Package, c.Package)
+		files[base+".go"] = render(commandTemplate, data)
+		files[base+"_test.go"] = render(commandTestTemplate, data)
+	}
+	for name, contents := range files {
+		b := []byte(contents)
+		if strings.HasSuffix(name, ".go") {
+			var err error
+			b, err = format.Source(b)
+			if err != nil {
+				return fmt.Errorf("formatting %s: %s", name, err)
+			}
+		}
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, b, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packageName returns a package name for the command called name.
+func packageName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	pkg := b.String()
+	if pkg == "" || unicode.IsDigit(rune(pkg[0])) || pkg == "main" {
+		pkg = "cmd" + pkg
+	}
+	return pkg
+}
+
+var funcs = template.FuncMap{
+	"join": path.Join,
+}
+
+func render(tmpl *template.Template, data interface{}) string {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
+
+var goModTemplate = template.Must(template.New("go.mod").Parse(`module {{.Module}}
+
+go 1.21
+`))
+
+var mainTemplate = template.Must(template.New("main.go").Funcs(funcs).Parse(`// Command {{.Name}} is ... (TODO: describe the program).
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/cespare/subcmd"
+{{range .Commands}}
+	"{{join $.Module "commands" .Package}}"
+{{- end}}
+)
+
+func main() {
+	cmds := []subcmd.Command{
+{{- range .Commands}}
+		{{.Package}}.Command(os.Stdout),
+{{- end}}
+	}
+	r := subcmd.New("{{.Name}}", cmds, flag.ExitOnError)
+	// The runner answers completion requests from shells itself (see
+	// subcmd.Runner.Complete); each command completes its own arguments.
+	r.Run(os.Args[1:])
+}
+`))
+
+var commandTemplate = template.Must(template.New("command.go").Parse(`// Package {{.Package}} implements the "{{.Program}} {{.Name}}" command.
+package {{.Package}}
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/cespare/subcmd"
+)
+
+// Command returns the {{.Name}} command, which writes its output to stdout.
+func Command(stdout io.Writer) subcmd.Command {
+	fs := flag.NewFlagSet("{{.Program}} {{.Name}}", flag.ExitOnError)
+	return subcmd.Command{
+		Name:        "{{.Name}}",
+		Description: "TODO: describe {{.Name}}",
+		Flags:       fs,
+		// The runner parses the flags in fs before calling Do with the
+		// remaining arguments.
+		Do: func(args []string) {
+			fmt.Fprintln(stdout, "{{.Name}}: not implemented yet")
+		},
+		Complete: complete,
+	}
+}
+
+// complete returns the completions for the final element of args.
+func complete(args []string) []string {
+	return nil
+}
+`))
+
+var commandTestTemplate = template.Must(template.New("command_test.go").Parse(`package {{.Package}}
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	var buf bytes.Buffer
+	Command(&buf).Do(nil)
+	if buf.Len() == 0 {
+		t.Error("{{.Name}} printed nothing")
+	}
+}
+`))
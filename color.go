@@ -0,0 +1,95 @@
+package subcmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// A ColorMode determines whether a Runner styles its output (such as error
+// messages) using ANSI escape sequences. It implements flag.Value so that it
+// can be set by a flag, using the values "auto", "always", and "never".
+type ColorMode int
+
+// These are the color modes. The zero value is ColorAuto.
+const (
+	// ColorAuto styles output written to a terminal, unless the NO_COLOR
+	// environment variable is set or TERM is "dumb".
+	ColorAuto ColorMode = iota
+	// ColorAlways always styles output.
+	ColorAlways
+	// ColorNever never styles output.
+	ColorNever
+)
+
+func (m *ColorMode) String() string {
+	switch *m {
+	case ColorAuto:
+		return "auto"
+	case ColorAlways:
+		return "always"
+	case ColorNever:
+		return "never"
+	}
+	return fmt.Sprintf("ColorMode(%d)", int(*m))
+}
+
+// Set implements flag.Value.
+func (m *ColorMode) Set(s string) error {
+	switch s {
+	case "auto":
+		*m = ColorAuto
+	case "always":
+		*m = ColorAlways
+	case "never":
+		*m = ColorNever
+	default:
+		return errors.New("must be auto, always, or never")
+	}
+	return nil
+}
+
+// Complete implements FlagCompleter.
+func (m *ColorMode) Complete(prefix string) []string {
+	return completeChoices([]string{"auto", "always", "never"}, prefix)
+}
+
+// enabled reports whether output written to w should be styled.
+func (m ColorMode) enabled(w io.Writer) bool {
+	switch m {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(w)
+}
+
+// isTerminal reports whether w is a terminal (or, on Windows, a console).
+func isTerminal(w io.Writer) bool {
+	f, ok := underlyingWriter(w).(*os.File)
+	return ok && fileIsTerminal(f)
+}
+
+// These are the SGR sequences used to style output.
+const (
+	sgrReset = "\x1b[0m"
+	sgrBold  = "\x1b[1m"
+	sgrDim   = "\x1b[2m"
+	sgrRed   = "\x1b[31m"
+)
+
+// style surrounds s with the SGR sequence sgr, if on is set.
+func style(on bool, sgr, s string) string {
+	if !on || s == "" {
+		return s
+	}
+	return sgr + s + sgrReset
+}
@@ -0,0 +1,26 @@
+package subcmd
+
+import (
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestIsTerminalDevNull(t *testing.T) {
+	switch runtime.GOOS {
+	case "linux", "darwin", "windows":
+	default:
+		t.Skip("terminals aren't told apart from other devices on " + runtime.GOOS)
+	}
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if isTerminal(f) {
+		t.Errorf("isTerminal(%s) = true", os.DevNull)
+	}
+	if ColorAuto.enabled(f) {
+		t.Errorf("ColorAuto is enabled for %s", os.DevNull)
+	}
+}
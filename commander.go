@@ -0,0 +1,38 @@
+package subcmd
+
+import "flag"
+
+// A Commander is a command implemented by a type, which may hold state of
+// its own. CommandOf turns a Commander into a Command.
+type Commander interface {
+	Name() string
+	Description() string
+	Run(args []string) error
+}
+
+// CommandOf returns a Command that runs c. If c also has a method
+//
+//	Flags() *flag.FlagSet
+//
+// the flags it returns are the command's Flags, which Run parses before
+// calling c.Run.
+func CommandOf(c Commander) Command {
+	cmd := Command{
+		Name:        c.Name(),
+		Description: c.Description(),
+		DoErr:       c.Run,
+	}
+	if f, ok := c.(interface{ Flags() *flag.FlagSet }); ok {
+		cmd.Flags = f.Flags()
+	}
+	return cmd
+}
+
+// CommandsOf returns the Commands that run each of cs, as by CommandOf.
+func CommandsOf(cs ...Commander) []Command {
+	cmds := make([]Command, len(cs))
+	for i, c := range cs {
+		cmds[i] = CommandOf(c)
+	}
+	return cmds
+}
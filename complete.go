@@ -0,0 +1,288 @@
+package subcmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A CompletionFunc returns candidate completions for a command's arguments.
+// The final element of args is the (possibly empty) word being completed;
+// the elements before it are the arguments that precede it on the command
+// line.
+type CompletionFunc func(args []string) []string
+
+// CacheCompletions returns a CompletionFunc that remembers the results of fn
+// for the duration ttl. It is intended for completions that are expensive to
+// compute, such as those that require a network request.
+//
+// Shells typically run a new process for every completion request, so the
+// results are cached in files beneath the user's cache directory. The key
+// identifies fn among all the cached completion functions (the full command
+// name, such as "prog foo", is a good choice); each cache entry is also
+// specific to the args passed to the returned function.
+//
+// If the cache cannot be used for any reason, the returned function calls
+// fn directly.
+func CacheCompletions(key string, ttl time.Duration, fn CompletionFunc) CompletionFunc {
+	return func(args []string) []string {
+		path, err := completionCachePath(key, args)
+		if err != nil {
+			return fn(args)
+		}
+		if completions, ok := readCompletionCache(path, ttl); ok {
+			return completions
+		}
+		completions := fn(args)
+		writeCompletionCache(path, completions)
+		return completions
+	}
+}
+
+func completionCachePath(key string, args []string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(key))
+	for _, arg := range args {
+		h.Write([]byte{0})
+		h.Write([]byte(arg))
+	}
+	name := hex.EncodeToString(h.Sum(nil))
+	return filepath.Join(dir, "subcmd", "completions", name), nil
+}
+
+func readCompletionCache(path string, ttl time.Duration) ([]string, bool) {
+	fi, err := os.Stat(path)
+	if err != nil || time.Since(fi.ModTime()) > ttl {
+		return nil, false
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var completions []string
+	if err := json.Unmarshal(b, &completions); err != nil {
+		return nil, false
+	}
+	return completions, true
+}
+
+// writeCompletionCache stores completions at path. Failures are ignored: the
+// worst outcome is that the completions are computed again next time.
+func writeCompletionCache(path string, completions []string) {
+	b, err := json.Marshal(completions)
+	if err != nil {
+		return
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+	f, err := ioutil.TempFile(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(b)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+// completeCommand is the hidden command through which shells request
+// completions from a program: "prog __complete foo b" prints the
+// completions for "prog foo b", one per line, on stdout.
+const completeCommand = "__complete"
+
+// Complete returns the candidate completions for args, the arguments
+// following the runner's name on a command line. The final element of args is
+// the (possibly empty) word being completed.
+//
+// If that word is the command name, Complete returns the names of the
+// matching commands. If it is a flag (beginning with "-") or the value of a
+// flag, Complete returns the matching flags of the command (or the global
+// flags, before the command name) or, if the flag's Value is a FlagCompleter,
+// the matching values. Otherwise, it delegates to the command's Complete
+// function or, if there is none, completes the choices of the command's Args.
+// The Subcommands of a command are completed in the same way. The
+// completions of a command that dispatches to another Runner by itself may be
+// provided by setting the command's Complete to the other Runner's Complete
+// method:
+//
+//	{Name: "remote", Do: runRemote, Complete: remote.Complete}
+func (r *Runner) Complete(args []string) []string {
+	if len(args) > 0 {
+		// Set aside the global flags preceding the command name.
+		last := args[len(args)-1]
+		rest := skipFlags(r.Flags, args[:len(args)-1])
+		if len(rest) == 0 {
+			if completions, ok := completeFlags(r.Flags, args); ok {
+				return completions
+			}
+		}
+		args = append(rest[:len(rest):len(rest)], last)
+	}
+	if len(args) <= 1 {
+		var prefix string
+		if len(args) == 1 {
+			prefix = args[0]
+		}
+		var completions []string
+		for _, cmd := range r.visibleCommands() {
+			if strings.HasPrefix(cmd.Name, prefix) {
+				completions = append(completions, cmd.Name)
+			}
+		}
+		return completions
+	}
+	cmd, ok := r.lookup(args[0])
+	if !ok || !r.available(cmd) {
+		return nil
+	}
+	if len(cmd.Subcommands) > 0 {
+		return r.child(cmd).Complete(args[1:])
+	}
+	if completions, ok := completeFlags(cmd.Flags, args[1:]); ok {
+		return completions
+	}
+	if cmd.Complete == nil {
+		return cmd.completeArgs(args[1:])
+	}
+	return cmd.Complete(args[1:])
+}
+
+func (r *Runner) printCompletions(args []string) {
+	w := r.Stdout()
+	for _, c := range r.Complete(args) {
+		fmt.Fprintln(w, c)
+	}
+}
+
+// A FlagCompleter is a flag.Value that can complete its own values, so that
+// Runner.Complete (and so the shell completion scripts) can complete the
+// values of the flags that use it.
+type FlagCompleter interface {
+	flag.Value
+	// Complete returns the candidate values that begin with prefix.
+	Complete(prefix string) []string
+}
+
+// completeFlags completes the final element of args, which follow a
+// command name, if it is a flag in fs or the value of one (and doesn't
+// follow "--"). It reports whether it did so.
+func completeFlags(fs *flag.FlagSet, args []string) ([]string, bool) {
+	if fs == nil || len(args) == 0 {
+		return nil, false
+	}
+	for _, arg := range args[:len(args)-1] {
+		if arg == "--" {
+			// The flags have ended.
+			return nil, false
+		}
+	}
+	word := args[len(args)-1]
+	if len(args) > 1 {
+		if f, ok := flagArg(fs, args[len(args)-2]); ok && !strings.Contains(args[len(args)-2], "=") && !isBoolFlag(f) {
+			return completeFlagValue(f, "", word), true
+		}
+	}
+	if !strings.HasPrefix(word, "-") {
+		return nil, false
+	}
+	if i := strings.Index(word, "="); i >= 0 {
+		f, ok := flagArg(fs, word[:i])
+		if !ok {
+			return nil, true
+		}
+		return completeFlagValue(f, word[:i+1], word[i+1:]), true
+	}
+	dashes := "-"
+	if strings.HasPrefix(word, "--") {
+		dashes = "--"
+	}
+	var completions []string
+	fs.VisitAll(func(f *flag.Flag) {
+		if name := dashes + f.Name; strings.HasPrefix(name, word) {
+			completions = append(completions, name)
+		}
+	})
+	return completions, true
+}
+
+// completeFlagValue returns the values of f beginning with prefix, each
+// preceded by lead.
+func completeFlagValue(f *flag.Flag, lead, prefix string) []string {
+	fc, ok := f.Value.(FlagCompleter)
+	if !ok {
+		return nil
+	}
+	var completions []string
+	for _, c := range fc.Complete(prefix) {
+		completions = append(completions, lead+c)
+	}
+	return completions
+}
+
+// flagArg returns the flag of fs named by the command-line argument arg,
+// such as "-n", "--n", or "-n=3". A nil fs has no flags.
+func flagArg(fs *flag.FlagSet, arg string) (*flag.Flag, bool) {
+	if fs == nil || !strings.HasPrefix(arg, "-") {
+		return nil, false
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	if i := strings.Index(name, "="); i >= 0 {
+		name = name[:i]
+	}
+	f := fs.Lookup(name)
+	return f, f != nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	bf, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && bf.IsBoolFlag()
+}
+
+// skipFlags returns args without the flags of fs (and their values) at the
+// beginning, as the flag package would parse them.
+func skipFlags(fs *flag.FlagSet, args []string) []string {
+	for len(args) > 0 {
+		f, ok := flagArg(fs, args[0])
+		switch {
+		case args[0] == "--":
+			return args[1:]
+		case !ok:
+			return args
+		case !strings.Contains(args[0], "=") && !isBoolFlag(f) && len(args) > 1:
+			args = args[2:]
+		default:
+			args = args[1:]
+		}
+	}
+	return args
+}
+
+// completeChoices returns the elements of choices that begin with prefix.
+func completeChoices(choices []string, prefix string) []string {
+	var completions []string
+	for _, c := range choices {
+		if strings.HasPrefix(c, prefix) {
+			completions = append(completions, c)
+		}
+	}
+	return completions
+}
@@ -0,0 +1,67 @@
+package subcmd
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	addFlags := flag.NewFlagSet("add", flag.ContinueOnError)
+	addFlags.Bool("force", false, "")
+	addFlags.String("name", "", "")
+	cmds := []Command{
+		{Name: "add", Flags: addFlags, Do: func([]string) {}},
+		{Name: "alias", Do: func([]string) {}},
+		{Name: "noflags", Do: func([]string) {}},
+		{
+			Name:        "remote",
+			Subcommands: []Command{{Name: "rm", Do: func([]string) {}}},
+		},
+		{Name: "secret", Hidden: true, Do: func([]string) {}},
+	}
+	for _, tt := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{""}, []string{"add", "alias", "noflags", "remote"}},
+		{[]string{"a"}, []string{"add", "alias"}},
+		{[]string{"add", "-f"}, []string{"-force"}},
+		{[]string{"add", "--n"}, []string{"--name"}},
+		{[]string{"add", "-name", "x", "-"}, []string{"-force", "-name"}},
+		{[]string{"add", "--", "-"}, nil},
+		{[]string{"add", "-force", "--", "-f"}, nil},
+		{[]string{"noflags", "-"}, nil},
+		{[]string{"remote", ""}, []string{"rm"}},
+		{[]string{"remote", "-"}, nil},
+		{[]string{"-"}, nil},
+		{[]string{"--", "-"}, nil},
+		{[]string{"bogus", ""}, nil},
+	} {
+		r := New("prog", cmds, flag.ContinueOnError)
+		r.Flags = nil
+		if got := r.Complete(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Complete(%q) = %q; want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestCompleteGlobalFlags(t *testing.T) {
+	r := New("prog", []Command{{Name: "add", Do: func([]string) {}}}, flag.ContinueOnError)
+	r.Flags.Bool("verbose", false, "")
+	r.Flags.String("config", "", "")
+	for _, tt := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-v"}, []string{"-verbose"}},
+		{[]string{"-verbose", "a"}, []string{"add"}},
+		{[]string{"-config", "x", ""}, []string{"add"}},
+		{[]string{"--", "-"}, nil},
+	} {
+		got := r.Complete(tt.args)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Complete(%q) = %q; want %q", tt.args, got, tt.want)
+		}
+	}
+}
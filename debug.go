@@ -0,0 +1,126 @@
+package subcmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// debugCommand is the name of the hidden command enabled by Runner.Debug.
+const debugCommand = "debug"
+
+// debugCommands returns the subcommands of the debug command.
+func (r *Runner) debugCommands() []Command {
+	return []Command{
+		{
+			Name:        "tree",
+			Description: "print every command, including hidden ones",
+			Do:          func([]string) { r.writeDebugTree(r.Stdout()) },
+		},
+		{
+			Name:        "flags",
+			Description: "print the flags of a command",
+			ArgsUsage:   "CMD",
+			DoErr:       func(args []string) error { return r.writeDebugFlags(r.Stdout(), args) },
+		},
+		{
+			Name:        "json",
+			Description: "print the command tree as JSON",
+			DoErr:       func([]string) error { return r.WriteCommandTree(r.Stdout()) },
+		},
+		{
+			Name:        "env",
+			Description: "print details of the program's environment",
+			Do:          func([]string) { r.writeDebugEnv(r.Stdout()) },
+		},
+	}
+}
+
+func (r *Runner) writeDebugTree(w io.Writer) {
+	fmt.Fprintln(w, r.name)
+	rows := make([][]string, len(r.cmds))
+	for i, cmd := range r.cmds {
+		var notes []string
+		if cmd.Hidden {
+			notes = append(notes, "hidden")
+		}
+		if cmd.Deprecated != "" {
+			notes = append(notes, "deprecated")
+		}
+		if cmd.Stability != Stable {
+			notes = append(notes, cmd.Stability.String())
+		}
+		if cmd.MinVersion != "" {
+			note := "since " + cmd.MinVersion
+			if !r.available(&cmd) {
+				note += ", unavailable"
+			}
+			notes = append(notes, note)
+		}
+		if cmd.Complete != nil {
+			notes = append(notes, "completes arguments")
+		}
+		if hasFlags(cmd.Flags) {
+			notes = append(notes, "flags")
+		}
+		if len(cmd.Subcommands) > 0 {
+			notes = append(notes, "subcommands")
+		}
+		rows[i] = []string{cmd.Name, strings.Join(notes, ", ")}
+	}
+	writeColumns(w, rows)
+}
+
+func (r *Runner) writeDebugFlags(w io.Writer, args []string) error {
+	if len(args) != 1 {
+		return errors.New("expected one command name")
+	}
+	cmd, ok := r.lookup(args[0])
+	if !ok {
+		return fmt.Errorf("no such command %s", quoteArg(args[0]))
+	}
+	if !hasFlags(cmd.Flags) {
+		fmt.Fprintf(w, "%s %s declares no flags\n", r.name, cmd.Name)
+		return nil
+	}
+	fmt.Fprintf(w, "%s %s:\n", r.name, cmd.Name)
+	writeFlags(w, cmd.Flags, cmd.RequiredFlags)
+	return nil
+}
+
+// debugEnvVars are the environment variables that commonly affect how
+// command-line programs behave.
+var debugEnvVars = []string{"TERM", "COLUMNS", "NO_COLOR", "PAGER", "SHELL", "LANG"}
+
+func (r *Runner) writeDebugEnv(w io.Writer) {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = fmt.Sprintf("(%s)", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		wd = fmt.Sprintf("(%s)", err)
+	}
+	version := r.Version
+	if version == "" {
+		version = "(unset)"
+	}
+	rows := [][]string{
+		{"program", r.name},
+		{"version", version},
+		{"executable", exe},
+		{"arguments", fmt.Sprintf("%q", os.Args)},
+		{"directory", wd},
+		{"go", runtime.Version()},
+		{"platform", runtime.GOOS + "/" + runtime.GOARCH},
+	}
+	for _, name := range debugEnvVars {
+		if v, ok := os.LookupEnv(name); ok {
+			rows = append(rows, []string{"$" + name, v})
+		}
+	}
+	writeColumns(w, rows)
+}
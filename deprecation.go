@@ -0,0 +1,68 @@
+package subcmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// deprecationsCommand is the name of the command enabled by
+// Runner.Deprecations.
+const deprecationsCommand = "deprecations"
+
+// warnDeprecated prints a notice to r's standard error if cmd is deprecated.
+func (r *Runner) warnDeprecated(cmd *Command) {
+	if cmd.Deprecated == "" {
+		return
+	}
+	msg := fmt.Sprintf(r.tr("warning: %s is deprecated: %s"), quoteArg(cmd.Name), cmd.Deprecated)
+	if cmd.ReplacedBy != "" {
+		msg += fmt.Sprintf(r.tr("; use %s instead"), quoteArg(cmd.ReplacedBy))
+	}
+	if cmd.RemovedIn != "" {
+		msg += fmt.Sprintf(r.tr(" (it will be removed in version %s)"), cmd.RemovedIn)
+	}
+	if r.ErrorPrefix != "" {
+		msg = r.ErrorPrefix + ": " + msg
+	}
+	fmt.Fprintln(r.Stderr(), msg)
+}
+
+// writeDeprecations writes the report of the "deprecations" command, which
+// lists the deprecated commands at every level, along with their aliases.
+func (r *Runner) writeDeprecations(w io.Writer) {
+	rows := [][]string{{"COMMAND", "REPLACEMENT", "REMOVAL", "NOTE"}}
+	rows = r.appendDeprecations(rows, "")
+	if len(rows) == 1 {
+		fmt.Fprintf(w, "%s has no deprecated commands.\n", r.name)
+		return
+	}
+	fmt.Fprintf(w, "Deprecated commands of %s:\n\n", r.name)
+	writeColumns(w, rows)
+}
+
+// appendDeprecations appends to rows a row for each deprecated command of r
+// and of its nested runners, naming each command by its path after prefix.
+func (r *Runner) appendDeprecations(rows [][]string, prefix string) [][]string {
+	for i := range r.cmds {
+		cmd := &r.cmds[i]
+		if cmd.Deprecated != "" {
+			name := prefix + cmd.Name
+			if len(cmd.Aliases) > 0 {
+				name += ", " + strings.Join(cmd.Aliases, ", ")
+			}
+			replacement, removal := "-", "-"
+			if cmd.ReplacedBy != "" {
+				replacement = cmd.ReplacedBy
+			}
+			if cmd.RemovedIn != "" {
+				removal = cmd.RemovedIn
+			}
+			rows = append(rows, []string{name, replacement, removal, cmd.Deprecated})
+		}
+		if len(cmd.Subcommands) > 0 {
+			rows = r.child(cmd).appendDeprecations(rows, prefix+cmd.Name+" ")
+		}
+	}
+	return rows
+}
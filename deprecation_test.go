@@ -0,0 +1,26 @@
+package subcmd
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestWriteDeprecationsNested(t *testing.T) {
+	cmds := []Command{{
+		Name: "remote",
+		Subcommands: []Command{{
+			Name:       "rm",
+			Aliases:    []string{"remove"},
+			Deprecated: "renamed",
+			ReplacedBy: "delete",
+			Do:         func([]string) {},
+		}},
+	}}
+	r := New("prog", cmds, flag.ContinueOnError)
+	var b strings.Builder
+	r.writeDeprecations(&b)
+	if !strings.Contains(b.String(), "remote rm, remove") || !strings.Contains(b.String(), "delete") {
+		t.Errorf("report lacks the nested command:\n%s", b.String())
+	}
+}
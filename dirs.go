@@ -0,0 +1,99 @@
+package subcmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ConfigDir returns the directory in which the program should keep the
+// user's configuration. It is a directory named after the program (the base
+// name of the first word of the runner's name, so that nested runners agree)
+// inside the platform's per-user configuration directory:
+//
+//   - $XDG_CONFIG_HOME or $HOME/.config on Unix systems
+//   - $HOME/Library/Application Support on macOS
+//   - %AppData% on Windows
+//   - $home/lib on Plan 9
+//
+// ConfigDir does not create the directory.
+func (r *Runner) ConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, r.programName()), nil
+}
+
+// StateDir returns the directory in which the program should keep state that
+// persists between runs but isn't configuration, such as command history.
+// Like ConfigDir, it is named after the program and lies inside a per-user
+// directory chosen according to the platform's conventions:
+//
+//   - $XDG_STATE_HOME or $HOME/.local/state on Unix systems
+//   - $HOME/Library/Application Support on macOS
+//   - %LocalAppData% on Windows
+//   - $home/lib on Plan 9
+//
+// StateDir does not create the directory.
+func (r *Runner) StateDir() (string, error) {
+	dir, err := userStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, r.programName()), nil
+}
+
+// programName returns the name of the program: the base name of the first
+// word of r's name, so that a runner named after os.Args[0] (such as
+// "/usr/local/bin/prog" or, on Windows, "prog.exe") gives "prog".
+func (r *Runner) programName() string {
+	name := r.name
+	if fields := strings.Fields(name); len(fields) > 0 {
+		name = fields[0]
+	}
+	name = filepath.Base(name)
+	if runtime.GOOS == "windows" {
+		if ext := filepath.Ext(name); strings.EqualFold(ext, ".exe") {
+			name = strings.TrimSuffix(name, ext)
+		}
+	}
+	return name
+}
+
+// baseName returns r's name with the program named as by programName, such
+// as "prog remote" for a runner named "/usr/local/bin/prog remote".
+func (r *Runner) baseName() string {
+	fields := strings.Fields(r.name)
+	if len(fields) == 0 {
+		return r.programName()
+	}
+	return strings.Join(append([]string{r.programName()}, fields[1:]...), " ")
+}
+
+// userStateDir is the counterpart of os.UserConfigDir for the XDG state
+// directory.
+func userStateDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+		return "", errors.New("%LocalAppData% is not defined")
+	case "darwin", "ios", "plan9":
+		return os.UserConfigDir()
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		if !filepath.IsAbs(dir) {
+			return "", errors.New("path in $XDG_STATE_HOME is relative")
+		}
+		return dir, nil
+	}
+	home := os.Getenv("HOME")
+	if home == "" {
+		return "", errors.New("neither $XDG_STATE_HOME nor $HOME are defined")
+	}
+	return filepath.Join(home, ".local", "state"), nil
+}
@@ -0,0 +1,37 @@
+package subcmd
+
+import (
+	"flag"
+	"path/filepath"
+	"testing"
+)
+
+func TestProgramName(t *testing.T) {
+	for _, name := range []string{
+		"prog",
+		"prog remote",
+		"./prog",
+		"/usr/local/bin/prog",
+		filepath.Join("bin", "prog") + " remote",
+	} {
+		r := New(name, nil, flag.ContinueOnError)
+		if got := r.programName(); got != "prog" {
+			t.Errorf("programName for %q: got %q; want \"prog\"", name, got)
+		}
+	}
+}
+
+func TestStateDirPathName(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/state")
+	t.Setenv("LocalAppData", "/state")
+	t.Setenv("XDG_CONFIG_HOME", "/config")
+	t.Setenv("AppData", "/config")
+	r := New("/usr/local/bin/prog", nil, flag.ContinueOnError)
+	dir, err := r.StateDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(dir) != "prog" || filepath.Base(filepath.Dir(dir)) == "bin" {
+		t.Errorf("StateDir: got %q; want a directory named prog", dir)
+	}
+}
@@ -0,0 +1,65 @@
+package subcmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A docPage describes a page of generated documentation for a runner or for
+// one of its commands.
+type docPage struct {
+	name    string    // the full name, such as "prog foo"
+	cmd     *Command  // the command, or nil for the program itself
+	subcmds []Command // the commands of a runner
+	parent  *docPage  // the page for the runner of the command
+}
+
+// slug returns the base name of the file for p, such as "prog-foo".
+func (p *docPage) slug() string {
+	return strings.Replace(p.name, " ", "-", -1)
+}
+
+// genDocTree calls write to write the page for r, and then the pages for
+// r's visible commands (recursively), to files in dir whose names are the
+// pages' slugs followed by ext. The Runner passed to write is the one whose
+// commands are listed on the page, or else the one that runs the command
+// that it documents. The pages name the program by its base name, even if
+// the runner is named by the path of its executable.
+func (r *Runner) genDocTree(dir, ext string, write func(r *Runner, w io.Writer, p *docPage) error) error {
+	return r.genDocPages(dir, ext, &docPage{name: r.baseName()}, write)
+}
+
+func (r *Runner) genDocPages(dir, ext string, page *docPage, write func(*Runner, io.Writer, *docPage) error) error {
+	page.subcmds = r.visibleCommands()
+	if err := writeDocFile(r, filepath.Join(dir, page.slug()+ext), page, write); err != nil {
+		return err
+	}
+	for i := range page.subcmds {
+		cmd := &page.subcmds[i]
+		sub := &docPage{name: page.name + " " + cmd.Name, cmd: cmd, parent: page}
+		var err error
+		if len(cmd.Subcommands) > 0 {
+			err = r.child(cmd).genDocPages(dir, ext, sub, write)
+		} else {
+			err = writeDocFile(r, filepath.Join(dir, sub.slug()+ext), sub, write)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeDocFile(r *Runner, name string, p *docPage, write func(*Runner, io.Writer, *docPage) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = write(r, f, p)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
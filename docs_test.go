@@ -0,0 +1,52 @@
+package subcmd
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenMarkdownTreePathName(t *testing.T) {
+	cmds := []Command{{
+		Name:        "remote",
+		Description: "manage remotes",
+		Subcommands: []Command{{Name: "add", Description: "add a remote", Do: func([]string) {}}},
+	}}
+	r := New("/usr/local/bin/prog", cmds, flag.ContinueOnError)
+	dir := t.TempDir()
+	if err := r.GenMarkdownTree(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"prog.md", "prog-remote.md", "prog-remote-add.md"} {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if strings.Contains(string(b), "/usr/local/bin") {
+			t.Errorf("%s names the program by its path:\n%s", name, b)
+		}
+	}
+}
+
+func TestGenManTreePathName(t *testing.T) {
+	cmds := []Command{{Name: "add", Description: "add a thing", Do: func([]string) {}}}
+	r := New("/usr/local/bin/prog", cmds, flag.ContinueOnError)
+	r.Version = "1.2.3"
+	dir := t.TempDir()
+	if err := r.GenManTree(dir); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "prog-add.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ".TH \"PROG-ADD\" 1 \"\" \"prog 1.2.3\"\n"; !strings.HasPrefix(string(b), want) {
+		t.Errorf("page begins %q; want %q", strings.SplitAfter(string(b), "\n")[0], want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "prog.1")); err != nil {
+		t.Error(err)
+	}
+}
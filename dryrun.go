@@ -0,0 +1,33 @@
+package subcmd
+
+import (
+	"context"
+	"os"
+	"strconv"
+)
+
+// DryRunFlag defines the global flag -dry-run on r.Flags for setting
+// r.DryRun.
+func (r *Runner) DryRunFlag() {
+	r.Flags.BoolVar(&r.DryRun, "dry-run", r.DryRun, "show what would be done without doing it")
+}
+
+// dryRunKey is the context key under which RunContext records a dry run.
+type dryRunKey struct{}
+
+// IsDryRun reports whether ctx, the context passed to a command's
+// DoContext function, belongs to a dry run (see Runner.DryRun).
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// dryRunFromEnv reports whether the environment variable named by
+// r.DryRunEnv asks for a dry run.
+func (r *Runner) dryRunFromEnv() bool {
+	if r.DryRunEnv == "" {
+		return false
+	}
+	dryRun, err := strconv.ParseBool(os.Getenv(r.DryRunEnv))
+	return err == nil && dryRun
+}
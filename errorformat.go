@@ -0,0 +1,47 @@
+package subcmd
+
+import "errors"
+
+// An ErrorFormat is a format in which a Runner prints errors.
+// It implements flag.Value so that it can be set by a flag.
+type ErrorFormat string
+
+// These are the supported error formats.
+const (
+	// TextErrors prints each error as a line of text, followed by the usage
+	// message if the error is a usage error.
+	TextErrors ErrorFormat = "text"
+	// JSONErrors prints each error as a JSON object with the fields "code"
+	// (the exit status), "command" (the full name of the command that
+	// failed, or of the runner), and "message". No usage message is
+	// printed.
+	JSONErrors ErrorFormat = "json"
+)
+
+func (f *ErrorFormat) String() string { return string(*f) }
+
+// Set implements flag.Value.
+func (f *ErrorFormat) Set(s string) error {
+	switch ErrorFormat(s) {
+	case TextErrors, JSONErrors:
+		*f = ErrorFormat(s)
+		return nil
+	}
+	return errors.New("must be text or json")
+}
+
+// ErrorFormatFlag defines the global flag -error-format on r.Flags for
+// setting r.ErrorFormat. This lets programs that run the program ask for
+// errors in JSON with "prog --error-format=json ...".
+func (r *Runner) ErrorFormatFlag() {
+	if r.ErrorFormat == "" {
+		r.ErrorFormat = TextErrors
+	}
+	r.Flags.Var(&r.ErrorFormat, "error-format", "print errors in the given `format` (text or json)")
+}
+
+type jsonError struct {
+	Code    int    `json:"code"`
+	Command string `json:"command"`
+	Message string `json:"message"`
+}
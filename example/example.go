@@ -3,7 +3,6 @@ package main
 import (
 	"flag"
 	"fmt"
-	"os"
 
 	"github.com/cespare/subcmd"
 )
@@ -12,7 +11,7 @@ var cmds = []subcmd.Command{
 	{
 		Name:        "foo",
 		Description: "perform foo tasks",
-		Do:          foo,
+		Subcommands: fooCmds,
 	},
 	{
 		Name:        "xyz",
@@ -34,12 +33,6 @@ var fooCmds = []subcmd.Command{
 	},
 }
 
-func foo(args []string) {
-	cmdName := fmt.Sprintf("%s foo", os.Args[0])
-	r := subcmd.New(cmdName, fooCmds, flag.ExitOnError)
-	r.Run(args)
-}
-
 func foobar(args []string) {
 	fs := flag.NewFlagSet("foo bar", flag.ExitOnError)
 	a := fs.Bool("a", false, "Set option a")
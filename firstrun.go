@@ -0,0 +1,32 @@
+package subcmd
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// firstRunFile is the name of the file in the state directory that records
+// that the program has been run before.
+const firstRunFile = "initialized"
+
+// firstRun calls r.FirstRun if the program hasn't been run before.
+func (r *Runner) firstRun() {
+	if r.FirstRun == nil {
+		return
+	}
+	dir, err := r.StateDir()
+	if err != nil {
+		return
+	}
+	name := filepath.Join(dir, firstRunFile)
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		return
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+	r.FirstRun()
+	if f, err := os.Create(name); err == nil {
+		f.Close()
+	}
+}
@@ -0,0 +1,410 @@
+package subcmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Formatter renders help output. Assigning a Formatter to
+// Runner.Formatter changes the layout of the Runner's usage message without
+// replacing Runner.Usage altogether.
+type Formatter interface {
+	// FormatList writes the usage message for the program name, whose
+	// sub-commands are cmds.
+	FormatList(w io.Writer, name string, cmds []Command) error
+	// FormatCommand writes the help message for a single command.
+	// The name is the full name used to invoke cmd (for instance,
+	// "prog cmd").
+	FormatCommand(w io.Writer, name string, cmd Command) error
+}
+
+// TableFormatter is the Formatter used by default. It lists the commands in a
+// table of names and descriptions.
+type TableFormatter struct {
+	// ArgsUsage adds a column between the names and descriptions showing
+	// each command's ArgsUsage.
+	ArgsUsage bool
+	// Aliases lists each command's aliases after its name.
+	Aliases bool
+	// Color styles the output with ANSI escape sequences: command names
+	// are bold and headings are dimmed. A Runner sets Color when it uses
+	// a TableFormatter to write to a destination for which its Color mode
+	// enables styling.
+	Color bool
+	// Width, if positive, is the width in columns of the output, to
+	// which the commands' descriptions are word-wrapped with a hanging
+	// indent. A Runner sets Width to the width of the terminal (or to the
+	// COLUMNS environment variable) if it is 0.
+	Width int
+	// Translate, if non-nil, translates the English text of the output,
+	// as described for Runner.Translate, which a Runner uses to set it.
+	Translate func(s string) string
+}
+
+// FormatList implements Formatter.
+func (f TableFormatter) FormatList(w io.Writer, name string, cmds []Command) error {
+	ew := &errWriter{w: w}
+	ew.printf("%s\n\n  %s COMMAND\n", f.heading("Usage"), name)
+	// Align the columns of all the groups.
+	widths := columnWidths(f.rows(cmds))
+	for _, g := range groupCommands(cmds, f.tr) {
+		ew.printf("\n%s\n\n", f.heading(g.heading))
+		writeAligned(ew, f.rows(g.cmds), widths, f.Width)
+	}
+	ew.printf("\n"+f.tr("Run '%s COMMAND -h' to see more information about a command.")+"\n", name)
+	return ew.err
+}
+
+type commandGroup struct {
+	heading string
+	cmds    []Command
+}
+
+// groupCommands groups cmds for listing. Commands with a Category are
+// grouped by category, in the order in which the categories first appear in
+// cmds. They are followed by the program's other commands and then by the
+// commands from elsewhere, grouped by their Origin in the order in which
+// those first appear. The headings of the groups are translated by tr.
+func groupCommands(cmds []Command, tr func(string) string) []commandGroup {
+	var categories, origins []commandGroup
+	var plain []Command
+	add := func(groups []commandGroup, heading string, cmd Command) []commandGroup {
+		for i := range groups {
+			if groups[i].heading == heading {
+				groups[i].cmds = append(groups[i].cmds, cmd)
+				return groups
+			}
+		}
+		return append(groups, commandGroup{heading: heading, cmds: []Command{cmd}})
+	}
+	for _, cmd := range cmds {
+		switch {
+		case cmd.Category != "":
+			categories = add(categories, cmd.Category, cmd)
+		case cmd.Origin != "":
+			origins = add(origins, fmt.Sprintf(tr("Commands provided by %s"), cmd.Origin), cmd)
+		default:
+			plain = append(plain, cmd)
+		}
+	}
+	groups := categories
+	if len(plain) > 0 || len(groups)+len(origins) == 0 {
+		heading := tr("Possible commands are")
+		if len(categories) > 0 {
+			heading = tr("Other commands")
+		}
+		groups = append(groups, commandGroup{heading: heading, cmds: plain})
+	}
+	return append(groups, origins...)
+}
+
+// FormatCommand implements Formatter.
+func (f TableFormatter) FormatCommand(w io.Writer, name string, cmd Command) error {
+	ew := &errWriter{w: w}
+	usageLine := name
+	if usage := cmd.argsUsage(); usage != "" {
+		usageLine += " " + usage
+	}
+	ew.printf("%s\n\n  %s\n", f.heading("Usage"), usageLine)
+	if desc := badgedDescription(&cmd); desc != "" {
+		ew.printf("\n%s\n", desc)
+	}
+	if long := strings.TrimSpace(cmd.Long); long != "" {
+		ew.printf("\n%s\n", long)
+	}
+	if len(cmd.Aliases) > 0 {
+		ew.printf("\n"+f.tr("Aliases: %s")+"\n", strings.Join(cmd.Aliases, ", "))
+	}
+	if cmd.Origin != "" {
+		ew.printf("\n"+f.tr("Provided by %s.")+"\n", cmd.Origin)
+	}
+	writeArgChoices(ew, &cmd)
+	if hasFlags(cmd.Flags) {
+		ew.printf("\n%s\n\n", f.heading("Flags"))
+		writeFlags(ew, cmd.Flags, cmd.RequiredFlags)
+	}
+	if len(cmd.Examples) > 0 {
+		ew.printf("\n%s\n\n", f.heading("Examples"))
+		writeExamples(ew, cmd.Examples, "  ")
+	}
+	if len(cmd.SeeAlso) > 0 {
+		ew.printf("\n"+f.tr("See also: %s")+"\n", strings.Join(seeAlsoNames(name, &cmd), ", "))
+	}
+	return ew.err
+}
+
+// heading returns the translated heading text followed by a colon, styled if
+// f.Color is set.
+func (f TableFormatter) heading(text string) string {
+	return style(f.Color, sgrDim, f.tr(text)+":")
+}
+
+// tr translates the message s using f.Translate.
+func (f TableFormatter) tr(s string) string {
+	if f.Translate == nil {
+		return s
+	}
+	return f.Translate(s)
+}
+
+func (f TableFormatter) writeTable(w io.Writer, cmds []Command) {
+	rows := f.rows(cmds)
+	writeAligned(w, rows, columnWidths(rows), f.Width)
+}
+
+// rows returns the rows of the table describing cmds.
+func (f TableFormatter) rows(cmds []Command) [][]string {
+	rows := make([][]string, len(cmds))
+	for i, cmd := range cmds {
+		name := style(f.Color, sgrBold, cmd.Name)
+		if f.Aliases && len(cmd.Aliases) > 0 {
+			name += ", " + strings.Join(cmd.Aliases, ", ")
+		}
+		if f.ArgsUsage {
+			rows[i] = []string{name, cmd.argsUsage(), badgedDescription(&cmd)}
+		} else {
+			rows[i] = []string{name, badgedDescription(&cmd)}
+		}
+	}
+	return rows
+}
+
+// writeColumns writes rows of cells as indented, aligned columns separated by
+// at least four spaces. Unlike a tabwriter, it measures cells using
+// displayWidth, so styled text doesn't throw off the alignment.
+func writeColumns(w io.Writer, rows [][]string) {
+	writeAligned(w, rows, columnWidths(rows), 0)
+}
+
+// columnWidths returns the display width of the widest cell in each column
+// of rows.
+func columnWidths(rows [][]string) []int {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := displayWidth(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	return widths
+}
+
+// writeAligned is like writeColumns, but uses the given column widths, which
+// may be wider than the cells of rows require. If wrap is positive, the last
+// cell of each row is word-wrapped so that the lines are at most wrap columns
+// wide, with the continuation lines indented to the start of the cell.
+func writeAligned(w io.Writer, rows [][]string, widths []int, wrap int) {
+	var b strings.Builder
+	for _, row := range rows {
+		var line strings.Builder
+		line.WriteString("  ")
+		for i, cell := range row {
+			if i < len(row)-1 {
+				line.WriteString(cell)
+				line.WriteString(strings.Repeat(" ", widths[i]-displayWidth(cell)+4))
+				continue
+			}
+			start := displayWidth(line.String())
+			if wrap-start < minWrapWidth {
+				line.WriteString(cell)
+				continue
+			}
+			for j, text := range wrapText(cell, wrap-start) {
+				if j > 0 {
+					line.WriteString("\n" + strings.Repeat(" ", start))
+				}
+				line.WriteString(text)
+			}
+		}
+		b.WriteString(strings.TrimRight(line.String(), " "))
+		b.WriteString("\n")
+	}
+	io.WriteString(w, b.String())
+}
+
+// badgedDescription returns cmd's description, prefixed by badges such as
+// "[beta]" if the command isn't stable or "[deprecated]" if it is
+// deprecated.
+func badgedDescription(cmd *Command) string {
+	var badges []string
+	if cmd.Stability != Stable {
+		badges = append(badges, "["+cmd.Stability.String()+"]")
+	}
+	if cmd.Deprecated != "" {
+		badges = append(badges, "[deprecated]")
+	}
+	if desc := cmd.description(); desc != "" {
+		badges = append(badges, desc)
+	}
+	return strings.Join(badges, " ")
+}
+
+func hasFlags(fs *flag.FlagSet) bool {
+	if fs == nil {
+		return false
+	}
+	var n int
+	fs.VisitAll(func(*flag.Flag) { n++ })
+	return n > 0
+}
+
+// writeFlags writes a description of each flag in fs in the same format as
+// flag.PrintDefaults, noting which of them are named in required.
+func writeFlags(w io.Writer, fs *flag.FlagSet, required []string) {
+	var b strings.Builder
+	fs.VisitAll(func(f *flag.Flag) {
+		line := "  -" + f.Name
+		name, usage := flag.UnquoteUsage(f)
+		if name != "" {
+			line += " " + name
+		}
+		// Single-letter boolean flags fit on one line with their usage.
+		if len(line) <= 4 {
+			line += "\t"
+		} else {
+			line += "\n    \t"
+		}
+		line += strings.Replace(usage, "\n", "\n    \t", -1)
+		if contains(required, f.Name) {
+			line += " (required)"
+		}
+		if def := flagDefault(f); def != "" {
+			line += " (default " + def + ")"
+		}
+		b.WriteString(line + "\n")
+	})
+	io.WriteString(w, b.String())
+}
+
+// seeAlsoNames returns the full names of the commands in cmd.SeeAlso, given
+// name, the full name of cmd.
+func seeAlsoNames(name string, cmd *Command) []string {
+	parent := name
+	if i := strings.LastIndex(name, " "); i >= 0 {
+		parent = name[:i]
+	}
+	names := make([]string, len(cmd.SeeAlso))
+	for i, other := range cmd.SeeAlso {
+		names[i] = parent + " " + other
+	}
+	return names
+}
+
+// writeExamples writes examples, each indented by prefix, with its
+// description indented further beneath it.
+func writeExamples(w io.Writer, examples []Example, prefix string) {
+	var b strings.Builder
+	for i, ex := range examples {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(prefix + ex.Command + "\n")
+		if desc := strings.TrimSpace(ex.Description); desc != "" {
+			b.WriteString(indent(desc, prefix+"    ") + "\n")
+		}
+	}
+	io.WriteString(w, b.String())
+}
+
+// flagDefault returns f's default value formatted for display, or the empty
+// string if the default is the zero value for the flag's type.
+func flagDefault(f *flag.Flag) string {
+	typ := reflect.TypeOf(f.Value)
+	var zero string
+	if typ.Kind() == reflect.Ptr {
+		if v, ok := reflect.New(typ.Elem()).Interface().(flag.Value); ok {
+			zero = v.String()
+		}
+	}
+	if f.DefValue == zero {
+		return ""
+	}
+	if g, ok := f.Value.(flag.Getter); ok {
+		if _, ok := g.Get().(string); ok {
+			return fmt.Sprintf("%q", f.DefValue)
+		}
+	}
+	return f.DefValue
+}
+
+// displayWidth returns the number of terminal columns occupied by s,
+// ignoring any ANSI escape sequences it contains.
+func displayWidth(s string) int {
+	return utf8.RuneCountInString(stripANSI(s))
+}
+
+// stripANSI removes ANSI CSI sequences (such as SGR color codes) and OSC
+// sequences (such as hyperlinks) from s.
+func stripANSI(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\x1b' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '[':
+			// CSI: parameter and intermediate bytes followed by a final
+			// byte in the range 0x40-0x7e.
+			i += 2
+			for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+				i++
+			}
+		case ']':
+			// OSC: terminated by BEL or by ST (ESC \).
+			i += 2
+			for i < len(s) && s[i] != '\a' {
+				if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+					i++
+					break
+				}
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return b.String()
+}
+
+// indent adds prefix to the beginning of each non-blank line of s.
+func indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// errWriter is an io.Writer that remembers the first error it encounters and
+// discards all writes after that.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) Write(b []byte) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	n, err := ew.w.Write(b)
+	ew.err = err
+	return n, err
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(ew, format, args...)
+}
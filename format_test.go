@@ -0,0 +1,33 @@
+package subcmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableFormatterSeeAlso(t *testing.T) {
+	cmd := Command{Name: "cp", ArgsUsage: "<src> <dst>", SeeAlso: []string{"mv"}}
+	var b strings.Builder
+	if err := (TableFormatter{}).FormatCommand(&b, "prog cp", cmd); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "See also: prog mv\n") {
+		t.Errorf("help lacks the right See also line:\n%s", b.String())
+	}
+	if !strings.Contains(b.String(), "prog cp <src> <dst>\n") {
+		t.Errorf("help lacks the synopsis:\n%s", b.String())
+	}
+}
+
+func TestFprintDefaultsArgsUsage(t *testing.T) {
+	cmds := []Command{{Name: "cp", ArgsUsage: "<src> <dst>", Description: "copy a file"}}
+	defer func(show bool) { ShowArgsUsage = show }(ShowArgsUsage)
+	for _, show := range []bool{false, true} {
+		ShowArgsUsage = show
+		var b strings.Builder
+		FprintDefaults(&b, cmds)
+		if got := strings.Contains(b.String(), "<src> <dst>"); got != show {
+			t.Errorf("with ShowArgsUsage = %t, got:\n%s", show, b.String())
+		}
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/cespare/subcmd
 
-go 1.13
+go 1.21
@@ -0,0 +1,46 @@
+package subcmd_test
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/cespare/subcmd"
+	"github.com/cespare/subcmd/subcmdtest"
+)
+
+func TestUsageAfterHelp(t *testing.T) {
+	cmds := []subcmd.Command{{Name: "foo", Description: "do foo", Do: func([]string) {}}}
+	r := subcmd.New("prog", cmds, flag.ExitOnError)
+	res := subcmdtest.Run(r, "help")
+	if !strings.Contains(res.Stdout, "do foo") || res.Stderr != "" {
+		t.Errorf("help: got stdout %q, stderr %q; want the usage on stdout", res.Stdout, res.Stderr)
+	}
+	res = subcmdtest.Run(r, "bogus")
+	if !strings.Contains(res.Stderr, "do foo") || res.Stdout != "" {
+		t.Errorf("unknown command after help: got stdout %q, stderr %q; want the usage on stderr", res.Stdout, res.Stderr)
+	}
+}
+
+func TestUsageAfterSettingsChange(t *testing.T) {
+	cmds := []subcmd.Command{
+		{Name: "foo", ArgsUsage: "FILE", Description: "do foo", Do: func([]string) {}},
+		{Name: "bar", Description: "do bar", Do: func([]string) {}},
+	}
+	r := subcmd.New("prog", cmds, flag.ExitOnError)
+	r.Formatter = subcmd.TableFormatter{}
+	subcmdtest.Run(r, "help")
+
+	r.Formatter = subcmd.TableFormatter{ArgsUsage: true}
+	if res := subcmdtest.Run(r, "help"); !strings.Contains(res.Stdout, "FILE") {
+		t.Errorf("after setting Formatter: got %q; want the ArgsUsage column", res.Stdout)
+	}
+	r.Order = subcmd.NameOrder
+	if res := subcmdtest.Run(r, "help"); strings.Index(res.Stdout, "bar") > strings.Index(res.Stdout, "foo") {
+		t.Errorf("after setting Order: got %q; want the commands sorted by name", res.Stdout)
+	}
+	r.Flags.Bool("verbose", false, "be verbose")
+	if res := subcmdtest.Run(r, "help"); !strings.Contains(res.Stdout, "-verbose") {
+		t.Errorf("after adding a flag: got %q; want the flag listed", res.Stdout)
+	}
+}
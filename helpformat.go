@@ -0,0 +1,244 @@
+package subcmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CompactFormatter is a Formatter that writes each help message on a single
+// line, in the style of traditional Unix usage messages:
+//
+//	usage: prog {add|list|remove}
+//	usage: prog add [-f] [-n count] NAME
+type CompactFormatter struct{}
+
+// FormatList implements Formatter.
+func (CompactFormatter) FormatList(w io.Writer, name string, cmds []Command) error {
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.Name
+	}
+	_, err := fmt.Fprintf(w, "usage: %s {%s}\n", name, strings.Join(names, "|"))
+	return err
+}
+
+// FormatCommand implements Formatter.
+func (CompactFormatter) FormatCommand(w io.Writer, name string, cmd Command) error {
+	_, err := fmt.Fprintf(w, "usage: %s\n", synopsis(name, &cmd))
+	return err
+}
+
+// DetailedFormatter is a Formatter that shows the synopsis of every command,
+// including its flags, along with its description.
+type DetailedFormatter struct{}
+
+// FormatList implements Formatter.
+func (DetailedFormatter) FormatList(w io.Writer, name string, cmds []Command) error {
+	ew := &errWriter{w: w}
+	ew.printf("Usage:\n\n  %s COMMAND [ARGS]\n\nCommands:\n", name)
+	for _, cmd := range cmds {
+		ew.printf("\n  %s\n", synopsis(name+" "+cmd.Name, &cmd))
+		if desc := badgedDescription(&cmd); desc != "" {
+			ew.printf("      %s\n", desc)
+		}
+	}
+	ew.printf("\nRun '%s COMMAND -h' to see more information about a command.\n", name)
+	return ew.err
+}
+
+// FormatCommand implements Formatter.
+func (DetailedFormatter) FormatCommand(w io.Writer, name string, cmd Command) error {
+	ew := &errWriter{w: w}
+	ew.printf("Synopsis:\n\n  %s\n", synopsis(name, &cmd))
+	desc := badgedDescription(&cmd)
+	long := strings.TrimSpace(cmd.Long)
+	if desc != "" || long != "" {
+		ew.printf("\nDescription:\n")
+	}
+	if desc != "" {
+		ew.printf("\n  %s\n", desc)
+	}
+	if long != "" {
+		ew.printf("\n%s\n", indent(long, "  "))
+	}
+	if cmd.Origin != "" {
+		ew.printf("\nProvided by %s.\n", cmd.Origin)
+	}
+	writeArgChoices(ew, &cmd)
+	if hasFlags(cmd.Flags) {
+		ew.printf("\nFlags:\n\n")
+		writeFlags(ew, cmd.Flags, cmd.RequiredFlags)
+	}
+	if len(cmd.Examples) > 0 {
+		ew.printf("\nExamples:\n\n")
+		writeExamples(ew, cmd.Examples, "  ")
+	}
+	if len(cmd.SeeAlso) > 0 {
+		ew.printf("\nSee also:\n\n  %s\n", strings.Join(seeAlsoNames(name, &cmd), ", "))
+	}
+	return ew.err
+}
+
+// ManFormatter is a Formatter that lays out help messages like the sections
+// of a manual page.
+type ManFormatter struct{}
+
+// manIndent is the indentation of the body of each section of a manual page.
+const manIndent = "       "
+
+// FormatList implements Formatter.
+func (ManFormatter) FormatList(w io.Writer, name string, cmds []Command) error {
+	ew := &errWriter{w: w}
+	ew.printf("NAME\n%s%s\n\n", manIndent, name)
+	ew.printf("SYNOPSIS\n%s%s COMMAND [ARGS]\n\n", manIndent, name)
+	ew.printf("COMMANDS\n")
+	for i, cmd := range cmds {
+		if i > 0 {
+			ew.printf("\n")
+		}
+		ew.printf("%s%s\n", manIndent, cmd.Name)
+		if desc := badgedDescription(&cmd); desc != "" {
+			ew.printf("%s       %s\n", manIndent, desc)
+		}
+	}
+	return ew.err
+}
+
+// FormatCommand implements Formatter.
+func (ManFormatter) FormatCommand(w io.Writer, name string, cmd Command) error {
+	ew := &errWriter{w: w}
+	ew.printf("NAME\n%s%s", manIndent, name)
+	if desc := cmd.description(); desc != "" {
+		ew.printf(" - %s", desc)
+	}
+	ew.printf("\n\nSYNOPSIS\n%s%s\n", manIndent, synopsis(name, &cmd))
+	if long := strings.TrimSpace(cmd.Long); long != "" {
+		ew.printf("\nDESCRIPTION\n%s\n", indent(long, manIndent))
+	}
+	if cmd.Stability != Stable {
+		ew.printf("\nSTABILITY\n%sThis command is %s.\n", manIndent, cmd.Stability)
+	}
+	if cmd.Origin != "" {
+		ew.printf("\nORIGIN\n%sProvided by %s.\n", manIndent, cmd.Origin)
+	}
+	section := "\nARGUMENTS\n"
+	for _, arg := range cmd.Args {
+		if len(arg.Choices) > 0 {
+			ew.printf("%s%s%s\n%s       one of %s\n", section, manIndent, arg.Name, manIndent, strings.Join(arg.Choices, ", "))
+			section = ""
+		}
+	}
+	if hasFlags(cmd.Flags) {
+		ew.printf("\nOPTIONS\n")
+		cmd.Flags.VisitAll(func(f *flag.Flag) {
+			ew.printf("%s-%s", manIndent, f.Name)
+			argName, usage := flag.UnquoteUsage(f)
+			if argName != "" {
+				ew.printf(" %s", argName)
+			}
+			ew.printf("\n%s       %s", manIndent, strings.Replace(usage, "\n", "\n"+manIndent+"       ", -1))
+			if contains(cmd.RequiredFlags, f.Name) {
+				ew.printf(" (required)")
+			}
+			if def := flagDefault(f); def != "" {
+				ew.printf(" (default %s)", def)
+			}
+			ew.printf("\n")
+		})
+	}
+	if len(cmd.Examples) > 0 {
+		ew.printf("\nEXAMPLES\n")
+		writeExamples(ew, cmd.Examples, manIndent)
+	}
+	if len(cmd.SeeAlso) > 0 {
+		ew.printf("\nSEE ALSO\n%s%s\n", manIndent, strings.Join(seeAlsoNames(name, &cmd), ", "))
+	}
+	return ew.err
+}
+
+// writeArgChoices writes the "Arguments:" section of cmd's help message,
+// which lists the choices of its positional arguments.
+func writeArgChoices(w io.Writer, cmd *Command) {
+	var choices [][]string
+	for _, arg := range cmd.Args {
+		if len(arg.Choices) > 0 {
+			choices = append(choices, []string{arg.Name, "one of " + strings.Join(arg.Choices, ", ")})
+		}
+	}
+	if len(choices) > 0 {
+		io.WriteString(w, "\nArguments:\n\n")
+		writeColumns(w, choices)
+	}
+}
+
+// synopsis returns a one-line summary of how to invoke cmd by the given
+// name, listing its flags (in brackets, unless they are required) followed
+// by its arguments:
+//
+//	prog add [-f] [-n count] -o file NAME
+func synopsis(name string, cmd *Command) string {
+	parts := []string{name}
+	if cmd.Flags != nil {
+		cmd.Flags.VisitAll(func(f *flag.Flag) {
+			part := "-" + f.Name
+			if argName, _ := flag.UnquoteUsage(f); argName != "" {
+				part += " " + argName
+			}
+			if !contains(cmd.RequiredFlags, f.Name) {
+				part = "[" + part + "]"
+			}
+			parts = append(parts, part)
+		})
+	}
+	if usage := cmd.argsUsage(); usage != "" {
+		parts = append(parts, usage)
+	}
+	return strings.Join(parts, " ")
+}
+
+// HelpFormatFlag defines the global flag -help-format on r.Flags for
+// choosing the layout of the runner's help messages. The flag's values are
+// "table" (the TableFormatter), "compact" (the CompactFormatter),
+// "detailed" (the DetailedFormatter), and "man" (the ManFormatter); setting
+// it replaces r.Formatter.
+func (r *Runner) HelpFormatFlag() {
+	r.Flags.Var(&helpFormatValue{r: r}, "help-format",
+		"show help in the given `layout` (table, compact, detailed, or man)")
+}
+
+var helpFormatters = map[string]Formatter{
+	"table":    TableFormatter{},
+	"compact":  CompactFormatter{},
+	"detailed": DetailedFormatter{},
+	"man":      ManFormatter{},
+}
+
+// helpFormatValue is the flag.Value of the -help-format flag.
+type helpFormatValue struct {
+	r    *Runner
+	name string
+}
+
+func (v *helpFormatValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.name
+}
+
+func (v *helpFormatValue) Complete(prefix string) []string {
+	return completeChoices([]string{"table", "compact", "detailed", "man"}, prefix)
+}
+
+func (v *helpFormatValue) Set(s string) error {
+	f, ok := helpFormatters[s]
+	if !ok {
+		return errors.New("must be table, compact, detailed, or man")
+	}
+	v.name = s
+	v.r.Formatter = f
+	return nil
+}
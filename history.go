@@ -0,0 +1,86 @@
+package subcmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// historyFile is the name of the file in the state directory that records
+// the commands run with Runner.RecordHistory.
+const historyFile = "history"
+
+// A HistoryEntry records a command that was run, as returned by
+// Runner.History.
+type HistoryEntry struct {
+	Time     time.Time `json:"time"`     // when the command started
+	Args     []string  `json:"args"`     // the command line, following the program name (e.g., ["remote", "add", "origin"])
+	ExitCode int       `json:"exitCode"` // the exit status of the command, as under ExitOnError
+}
+
+// History returns the commands recorded by runs of the program with
+// RecordHistory set, oldest first. It returns no entries, and no error, if
+// none have been recorded.
+func (r *Runner) History() ([]HistoryEntry, error) {
+	dir, err := r.StateDir()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(dir, historyFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// Skip entries cut short by a crash or written by a future
+			// version of the program.
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// recordHistory appends an entry to the history file for the command line
+// args, which started at start and ended with cmdErr. Failures are ignored:
+// a missing history entry shouldn't make the command fail.
+func (r *Runner) recordHistory(start time.Time, args []string, cmdErr error) {
+	dir, err := r.StateDir()
+	if err != nil {
+		return
+	}
+	// The args of a Subcommands runner follow the names of the commands
+	// that lead to it.
+	var path []string
+	if fields := strings.Fields(r.name); len(fields) > 1 {
+		path = fields[1:]
+	}
+	b, err := json.Marshal(HistoryEntry{
+		Time:     start,
+		Args:     append(path, args...),
+		ExitCode: r.exitCode(cmdErr),
+	})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(dir, historyFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	f.Write(append(b, '\n'))
+	f.Close()
+}
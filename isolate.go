@@ -0,0 +1,89 @@
+package subcmd
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// isolatedEnv is the environment variable that marks a child process
+// started by a runner with Isolate set.
+const isolatedEnv = "SUBCMD_ISOLATED"
+
+// runIsolated runs cmd by re-executing the program with the arguments argv
+// (the command line that led to cmd) in a child process and waiting for it
+// to finish.
+func (r *Runner) runIsolated(cmd *Command, argv []string) error {
+	eh := r.errorHandlingFor(cmd)
+	args := []string{cmd.Name}
+	d, fromEnv, err := r.commandTimeout(cmd)
+	if err != nil {
+		return r.handleError(eh, args, err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return r.handleError(eh, args, r.errorf("cannot run %s in a child process: %w", quoteArg(cmd.Name), err))
+	}
+	child := exec.Command(exe, argv...)
+	child.Stdin = r.Stdin()
+	child.Stdout = r.Stdout()
+	child.Stderr = r.Stderr()
+	child.Env = append(childEnv(r.TimeoutEnv), isolatedEnv+"=1")
+	if err := child.Start(); err != nil {
+		return r.handleError(eh, args, r.errorf("cannot run %s in a child process: %w", quoteArg(cmd.Name), err))
+	}
+	stop := func() bool { return true }
+	if d > 0 {
+		t := time.AfterFunc(d, func() { child.Process.Kill() })
+		stop = t.Stop
+	}
+	err = child.Wait()
+	timedOut := !stop()
+	if err == nil {
+		return nil
+	}
+	path := r.name + " " + cmd.Name
+	code := 2
+	if timedOut {
+		err = r.timeoutError(d, fromEnv)
+		code = timeoutExitCode
+	} else if ee, ok := err.(*exec.ExitError); ok && ee.ExitCode() > 0 {
+		if eh == flag.ExitOnError && !r.chain.fail(ee.ExitCode()) {
+			// The child has reported its own failure.
+			r.Exit(ee.ExitCode())
+		}
+		err = r.errorf("command %s failed: %s", quoteArg(cmd.Name), ee)
+	} else {
+		err = r.errorf("command %s crashed: %w", quoteArg(cmd.Name), err)
+	}
+	switch eh {
+	case flag.ContinueOnError:
+		return err
+	case flag.PanicOnError:
+		panic(err)
+	}
+	r.printError(err, code, path)
+	if r.chain.fail(code) {
+		return err
+	}
+	r.Exit(code)
+	panic("unreached")
+}
+
+// childEnv returns the environment of the program without the variable
+// called omit.
+func childEnv(omit string) []string {
+	env := os.Environ()
+	if omit == "" {
+		return env
+	}
+	var filtered []string
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, omit+"=") {
+			filtered = append(filtered, kv)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,30 @@
+package subcmd
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// logStart logs the start of cmd, run with args, to r.Logger.
+func (r *Runner) logStart(ctx context.Context, cmd *Command, args []string) {
+	r.Logger.Log(ctx, slog.LevelDebug, "command started",
+		slog.String("command", r.name+" "+cmd.Name),
+		slog.Any("args", args))
+}
+
+// logDone logs the end of cmd, which was run with args, took dur, and
+// ended with err, to r.Logger.
+func (r *Runner) logDone(ctx context.Context, cmd *Command, args []string, dur time.Duration, err error) {
+	attrs := []slog.Attr{
+		slog.String("command", r.name+" "+cmd.Name),
+		slog.Any("args", args),
+		slog.Duration("duration", dur),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+		r.Logger.LogAttrs(ctx, slog.LevelError, "command failed", attrs...)
+		return
+	}
+	r.Logger.LogAttrs(ctx, slog.LevelInfo, "command finished", attrs...)
+}
@@ -0,0 +1,146 @@
+package subcmd
+
+import (
+	"flag"
+	"io"
+	"strings"
+)
+
+// GenManTree writes roff manual pages (in section 1) for the program and
+// each of its visible commands, including Subcommands, to the directory dir,
+// which must exist. The page for a program called prog is named prog.1 and
+// the page for its command foo is named prog-foo.1. The pages are made from
+// the commands' descriptions, Long documentation, arguments, flags, and
+// examples.
+func (r *Runner) GenManTree(dir string) error {
+	return r.genDocTree(dir, ".1", (*Runner).writeMan)
+}
+
+// writeMan writes the manual page p to w.
+func (r *Runner) writeMan(w io.Writer, p *docPage) error {
+	subcmds := p.subcmds
+	ew := &errWriter{w: w}
+	title := strings.ToUpper(p.slug())
+	ew.printf(".TH %s 1 \"\" %s\n", roffQuote(title), roffQuote(strings.TrimSpace(r.programName()+" "+r.Version)))
+	var desc, long string
+	if p.cmd != nil {
+		desc = p.cmd.description()
+		long = strings.TrimSpace(p.cmd.Long)
+	}
+	ew.printf(".SH NAME\n%s", roffEscape(p.slug()))
+	if desc != "" {
+		ew.printf(" \\- %s", roffEscape(desc))
+	}
+	ew.printf("\n.SH SYNOPSIS\n")
+	switch {
+	case len(subcmds) > 0:
+		ew.printf(".B %s\n.I COMMAND\n[\\fIARGS\\fR]\n", roffEscape(p.name))
+	case p.cmd != nil:
+		ew.printf("%s\n", strings.Replace(roffEscape(synopsis(p.name, p.cmd)), "-", `\-`, -1))
+	}
+	if long != "" {
+		ew.printf(".SH DESCRIPTION\n")
+		for i, para := range strings.Split(long, "\n\n") {
+			if i > 0 {
+				ew.printf(".PP\n")
+			}
+			ew.printf("%s\n", roffEscape(strings.TrimSpace(para)))
+		}
+	}
+	if p.cmd != nil {
+		var section bool
+		for _, arg := range p.cmd.Args {
+			if len(arg.Choices) == 0 {
+				continue
+			}
+			if !section {
+				ew.printf(".SH ARGUMENTS\n")
+				section = true
+			}
+			ew.printf(".TP\n.I %s\none of %s\n", roffEscape(arg.Name), roffEscape(strings.Join(arg.Choices, ", ")))
+		}
+	}
+	if len(subcmds) > 0 {
+		ew.printf(".SH COMMANDS\n")
+		for _, cmd := range subcmds {
+			ew.printf(".TP\n.B %s\n%s\n", roffEscape(cmd.Name), roffEscape(badgedDescription(&cmd)))
+		}
+	}
+	var flags *flag.FlagSet
+	var required []string
+	switch {
+	case len(subcmds) > 0:
+		flags = r.Flags
+	case p.cmd != nil:
+		flags = p.cmd.Flags
+		required = p.cmd.RequiredFlags
+	}
+	if hasFlags(flags) {
+		ew.printf(".SH OPTIONS\n")
+		flags.VisitAll(func(f *flag.Flag) {
+			name, usage := flag.UnquoteUsage(f)
+			ew.printf(".TP\n\\fB\\-%s\\fR", roffEscape(f.Name))
+			if name != "" {
+				ew.printf(" \\fI%s\\fR", roffEscape(name))
+			}
+			ew.printf("\n%s", roffEscape(usage))
+			if contains(required, f.Name) {
+				ew.printf(" (required)")
+			}
+			if def := flagDefault(f); def != "" {
+				ew.printf(" (default %s)", roffEscape(def))
+			}
+			ew.printf("\n")
+		})
+	}
+	if p.cmd != nil && len(p.cmd.Examples) > 0 {
+		ew.printf(".SH EXAMPLES\n")
+		for _, ex := range p.cmd.Examples {
+			ew.printf(".TP\n\\fB%s\\fR\n", strings.Replace(roffEscape(ex.Command), "-", `\-`, -1))
+			if desc := strings.TrimSpace(ex.Description); desc != "" {
+				ew.printf("%s\n", roffEscape(desc))
+			}
+		}
+	}
+	var seeAlso []string
+	if p.parent != nil {
+		seeAlso = append(seeAlso, p.parent.slug())
+	}
+	for _, cmd := range subcmds {
+		seeAlso = append(seeAlso, strings.Replace(p.name+" "+cmd.Name, " ", "-", -1))
+	}
+	if p.cmd != nil {
+		for _, name := range seeAlsoNames(p.name, p.cmd) {
+			seeAlso = append(seeAlso, strings.Replace(name, " ", "-", -1))
+		}
+	}
+	if len(seeAlso) > 0 {
+		ew.printf(".SH SEE ALSO\n")
+		for i, name := range seeAlso {
+			sep := ","
+			if i == len(seeAlso)-1 {
+				sep = ""
+			}
+			ew.printf(".BR %s (1)%s\n", roffEscape(name), sep)
+		}
+	}
+	return ew.err
+}
+
+// roffEscape escapes s for use as text in a roff document.
+func roffEscape(s string) string {
+	s = strings.Replace(s, `\`, `\e`, -1)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		// A line beginning with a period or apostrophe is a request.
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// roffQuote quotes s as an argument to a roff request.
+func roffQuote(s string) string {
+	return `"` + strings.Replace(roffEscape(s), `"`, `""`, -1) + `"`
+}
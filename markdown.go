@@ -0,0 +1,99 @@
+package subcmd
+
+import (
+	"io"
+	"strings"
+)
+
+// GenMarkdownTree writes a Markdown page for the program and for each of its
+// visible commands, including Subcommands, to the directory dir, which must
+// exist. The page for a program called prog is named prog.md and the page
+// for its command foo is named prog-foo.md. Each page shows the command's
+// name, synopsis, description, Long documentation, arguments, flags, and
+// examples, and links to the pages of related commands, so that the pages
+// are suitable for publishing on a documentation site or wiki.
+func (r *Runner) GenMarkdownTree(dir string) error {
+	return r.genDocTree(dir, ".md", (*Runner).writeMarkdown)
+}
+
+// writeMarkdown writes the Markdown page p to w.
+func (r *Runner) writeMarkdown(w io.Writer, p *docPage) error {
+	ew := &errWriter{w: w}
+	ew.printf("# %s\n", p.name)
+	flags := r.Flags
+	var required []string
+	if p.cmd != nil {
+		if desc := badgedDescription(p.cmd); desc != "" {
+			ew.printf("\n%s\n", desc)
+		}
+		if len(p.subcmds) == 0 {
+			flags = p.cmd.Flags
+			required = p.cmd.RequiredFlags
+		}
+	}
+	ew.printf("\n## Synopsis\n\n```\n")
+	if len(p.subcmds) > 0 {
+		ew.printf("%s COMMAND [ARGS]\n", p.name)
+	} else {
+		ew.printf("%s\n", synopsis(p.name, p.cmd))
+	}
+	ew.printf("```\n")
+	if p.cmd != nil {
+		if long := strings.TrimSpace(p.cmd.Long); long != "" {
+			ew.printf("\n%s\n", long)
+		}
+		if len(p.cmd.Aliases) > 0 {
+			ew.printf("\nAliases: %s\n", strings.Join(p.cmd.Aliases, ", "))
+		}
+		var section bool
+		for _, arg := range p.cmd.Args {
+			if len(arg.Choices) == 0 {
+				continue
+			}
+			if !section {
+				ew.printf("\n## Arguments\n\n")
+				section = true
+			}
+			ew.printf("* `%s`: one of %s\n", arg.Name, "`"+strings.Join(arg.Choices, "`, `")+"`")
+		}
+	}
+	if len(p.subcmds) > 0 {
+		ew.printf("\n## Commands\n\n")
+		for _, cmd := range p.subcmds {
+			sub := docPage{name: p.name + " " + cmd.Name}
+			ew.printf("* [%s](%s.md)", sub.name, sub.slug())
+			if desc := badgedDescription(&cmd); desc != "" {
+				ew.printf(": %s", desc)
+			}
+			ew.printf("\n")
+		}
+	}
+	if hasFlags(flags) {
+		ew.printf("\n## Flags\n\n```\n")
+		writeFlags(ew, flags, required)
+		ew.printf("```\n")
+	}
+	if p.cmd != nil && len(p.cmd.Examples) > 0 {
+		ew.printf("\n## Examples\n")
+		for _, ex := range p.cmd.Examples {
+			if desc := strings.TrimSpace(ex.Description); desc != "" {
+				ew.printf("\n%s\n", desc)
+			}
+			ew.printf("\n```\n%s\n```\n", ex.Command)
+		}
+	}
+	var related []string
+	if p.parent != nil {
+		related = append(related, p.parent.name)
+	}
+	if p.cmd != nil {
+		related = append(related, seeAlsoNames(p.name, p.cmd)...)
+	}
+	if len(related) > 0 {
+		ew.printf("\n## See also\n\n")
+		for _, name := range related {
+			ew.printf("* [%s](%s.md)\n", name, (&docPage{name: name}).slug())
+		}
+	}
+	return ew.err
+}
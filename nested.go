@@ -0,0 +1,51 @@
+package subcmd
+
+// child returns the Runner for the Subcommands of cmd, which inherits r's
+// settings.
+func (r *Runner) child(cmd *Command) *Runner {
+	// The Subcommands were checked along with cmd.
+	c := newRunner(r.name+" "+cmd.Name, cmd.Subcommands, r.errorHandlingFor(cmd))
+	if cmd.Flags != nil {
+		c.Flags = cmd.Flags
+	}
+	if r.ErrorPrefix != r.name {
+		// The prefix was customized (or disabled), so keep it as is rather
+		// than naming the child.
+		c.ErrorPrefix = r.ErrorPrefix
+	}
+	c.ErrorFormat = r.ErrorFormat
+	c.Streams = r.Streams
+	c.Color = r.Color
+	c.Formatter = r.Formatter
+	c.HelpExitCode = r.HelpExitCode
+	c.NoCommandExitCode = r.NoCommandExitCode
+	c.Version = r.Version
+	c.AlphaFlag = r.AlphaFlag
+	c.TimeoutEnv = r.TimeoutEnv
+	c.FirstRun = r.FirstRun
+	c.Isolate = r.Isolate
+	c.HelpWords = r.HelpWords
+	c.SlashHelp = r.SlashHelp
+	c.Before = r.Before
+	c.After = r.After
+	c.Middleware = r.Middleware
+	c.Plugins = r.Plugins
+	c.Exit = r.Exit
+	c.CommandNotFound = r.CommandNotFound
+	c.Translate = r.Translate
+	c.IgnoreCase = r.IgnoreCase
+	c.RecoverPanics = r.RecoverPanics
+	c.HandleSignals = r.HandleSignals
+	c.Order = r.Order
+	c.PageHelp = r.PageHelp
+	c.Picker = r.Picker
+	c.RecordHistory = r.RecordHistory
+	c.OnDispatch = r.OnDispatch
+	c.Logger = r.Logger
+	c.DryRun = r.DryRun
+	c.DryRunEnv = r.DryRunEnv
+	c.Verbosity = r.Verbosity
+	c.chain = r.chain
+	c.argv = r.argv
+	return c
+}
@@ -0,0 +1,59 @@
+package subcmd
+
+import (
+	"errors"
+	"flag"
+	"testing"
+)
+
+func TestChildErrorPrefix(t *testing.T) {
+	cmds := []Command{{
+		Name:        "remote",
+		Subcommands: []Command{{Name: "add", Do: func([]string) {}}},
+	}}
+	for _, tt := range []struct {
+		prefix string
+		want   string
+	}{
+		{"prog", "prog remote: no such command \"xyz\""},
+		{"", "no such command \"xyz\""},
+		{"error", "error: no such command \"xyz\""},
+	} {
+		r := New("prog", cmds, flag.ContinueOnError)
+		r.ErrorPrefix = tt.prefix
+		var notFound string
+		r.CommandNotFound = func(name string) { notFound = name }
+		err := r.Run([]string{"remote", "xyz"})
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("with ErrorPrefix %q: got error %v; want %q", tt.prefix, err, tt.want)
+		}
+		if notFound != "xyz" {
+			t.Errorf("with ErrorPrefix %q: CommandNotFound called with %q; want \"xyz\"", tt.prefix, notFound)
+		}
+	}
+}
+
+func TestNestedCommandError(t *testing.T) {
+	cmds := []Command{{
+		Name: "remote",
+		Subcommands: []Command{{
+			Name:  "add",
+			DoErr: func([]string) error { return errors.New("boom") },
+		}},
+	}}
+	for _, tt := range []struct {
+		prefix string
+		want   string
+	}{
+		{"prog", "prog remote add: boom"},
+		{"", "prog remote add: boom"},
+		{"error", "error: prog remote add: boom"},
+	} {
+		r := New("prog", cmds, flag.ContinueOnError)
+		r.ErrorPrefix = tt.prefix
+		err := r.Run([]string{"remote", "add"})
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("with ErrorPrefix %q: got error %v; want %q", tt.prefix, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package subcmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// An Output is a format in which a command may print its results.
+// It implements flag.Value so that it can be set by a flag.
+type Output string
+
+// These are the supported output formats.
+const (
+	TextOutput Output = "text" // human-oriented text
+	JSONOutput Output = "json" // JSON
+	YAMLOutput Output = "yaml" // YAML
+)
+
+func (o *Output) String() string { return string(*o) }
+
+// Set implements flag.Value.
+func (o *Output) Set(s string) error {
+	switch Output(s) {
+	case TextOutput, JSONOutput, YAMLOutput:
+		*o = Output(s)
+		return nil
+	}
+	return errors.New("must be text, json, or yaml")
+}
+
+// OutputFlag defines the global flags -o and -output on r.Flags for choosing
+// the program's output format. The format defaults to TextOutput. OutputFlag
+// returns the variable that holds the chosen format; commands should pass
+// their results to its Print method so that all of the program's commands
+// honor the flag in the same way:
+//
+//	var output = r.OutputFlag()
+//
+//	func list(args []string) {
+//		items := loadItems()
+//		output.Print(r.Stdout(), items, func(w io.Writer) error {
+//			for _, item := range items {
+//				fmt.Fprintln(w, item.Name)
+//			}
+//			return nil
+//		})
+//	}
+func (r *Runner) OutputFlag() *Output {
+	o := TextOutput
+	const usage = "output `format` (text, json, or yaml)"
+	r.Flags.Var(&o, "o", usage)
+	r.Flags.Var(&o, "output", usage)
+	return &o
+}
+
+// Complete implements FlagCompleter.
+func (o *Output) Complete(prefix string) []string {
+	return completeChoices([]string{string(TextOutput), string(JSONOutput), string(YAMLOutput)}, prefix)
+}
+
+// Print writes v to w as JSON or YAML, according to o. For TextOutput (or
+// the empty Output), Print calls text to write v in a human-oriented form.
+func (o Output) Print(w io.Writer, v interface{}, text func(w io.Writer) error) error {
+	switch o {
+	case "", TextOutput:
+		return text(w)
+	case JSONOutput:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case YAMLOutput:
+		return writeYAML(w, v)
+	}
+	return fmt.Errorf("subcmd: unknown output format %q", string(o))
+}
+
+// writeYAML writes v as a YAML document. The value is first converted to
+// JSON, so v is represented in the same way as it would be by
+// encoding/json.
+func writeYAML(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, line := range yamlLines(generic) {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// yamlLines returns the lines of the YAML representation of v, which is a
+// value decoded from JSON.
+func yamlLines(v interface{}) []string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return []string{"{}"}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var lines []string
+		for _, k := range keys {
+			key := yamlString(k) + ":"
+			sub := yamlLines(v[k])
+			if isYAMLScalar(v[k]) {
+				lines = append(lines, key+" "+sub[0])
+				continue
+			}
+			lines = append(lines, key)
+			for _, line := range sub {
+				lines = append(lines, "  "+line)
+			}
+		}
+		return lines
+	case []interface{}:
+		if len(v) == 0 {
+			return []string{"[]"}
+		}
+		var lines []string
+		for _, elem := range v {
+			for i, line := range yamlLines(elem) {
+				if i == 0 {
+					lines = append(lines, "- "+line)
+				} else {
+					lines = append(lines, "  "+line)
+				}
+			}
+		}
+		return lines
+	case string:
+		return []string{yamlString(v)}
+	case json.Number:
+		return []string{v.String()}
+	case bool:
+		return []string{fmt.Sprint(v)}
+	case nil:
+		return []string{"null"}
+	}
+	panic(fmt.Sprintf("subcmd: unexpected JSON value of type %T", v))
+}
+
+// isYAMLScalar reports whether v is written on the same line as its map key.
+func isYAMLScalar(v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return true
+}
+
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_./ -]*$`)
+
+// yamlString formats s as a YAML scalar, quoting it unless it is
+// unambiguously a plain string.
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !strings.HasSuffix(s, " ") {
+		switch strings.ToLower(s) {
+		case "true", "false", "yes", "no", "on", "off", "y", "n", "null":
+		default:
+			return s
+		}
+	}
+	// A JSON string is a valid double-quoted YAML scalar.
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,91 @@
+package subcmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestYAMLString(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		want string
+	}{
+		{"hello", "hello"},
+		{"hello world", "hello world"},
+		{"/usr/bin", "/usr/bin"},
+		{"v1.2-rc", "v1.2-rc"},
+		{"", `""`},
+		{"yes", `"yes"`},
+		{"No", `"No"`},
+		{"null", `"null"`},
+		{"true", `"true"`},
+		{"~", `"~"`},
+		{"123", `"123"`},
+		{"1.5", `"1.5"`},
+		{"-x", `"-x"`},
+		{"- x", `"- x"`},
+		{":x", `":x"`},
+		{"a: b", `"a: b"`},
+		{"#x", `"#x"`},
+		{"a #b", `"a #b"`},
+		{"trailing ", `"trailing "`},
+		{" leading", `" leading"`},
+		{"two\nlines", `"two\nlines"`},
+		{`say "hi"`, `"say \"hi\""`},
+	} {
+		if got := yamlString(tt.s); got != tt.want {
+			t.Errorf("yamlString(%q) = %s; want %s", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	type item struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	for _, tt := range []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"scalar", 3, "3\n"},
+		{"null", nil, "null\n"},
+		{"empty map", map[string]int{}, "{}\n"},
+		{"empty slice", []int{}, "[]\n"},
+		{
+			"sorted keys",
+			map[string]interface{}{"b": 2, "a": "yes", "c": true},
+			"a: \"yes\"\nb: 2\nc: true\n",
+		},
+		{
+			"nested map",
+			map[string]interface{}{"outer": map[string]interface{}{"inner": "x", "empty": []int{}}},
+			"outer:\n  empty: []\n  inner: x\n",
+		},
+		{
+			"slice of structs",
+			[]item{{"a", []string{"x", "-y"}}, {"b", nil}},
+			"- name: a\n  tags:\n    - x\n    - \"-y\"\n- name: b\n  tags: null\n",
+		},
+		{
+			"nested slices",
+			[][]int{{1, 2}, {3}},
+			"- - 1\n  - 2\n- - 3\n",
+		},
+		{
+			"quoted key",
+			map[string]string{"a: b": "", "null": "multi\nline"},
+			"\"a: b\": \"\"\n\"null\": \"multi\\nline\"\n",
+		},
+	} {
+		var b strings.Builder
+		if err := writeYAML(&b, tt.v); err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if got := b.String(); got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package subcmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// defaultPager is the pager used for help if the PAGER environment variable
+// is unset. The flags make less exit at once if the text fits on one screen
+// (-F), pass through color escape sequences (-R), and leave the text on the
+// screen afterwards (-X).
+const defaultPager = "less -FRX"
+
+// A pageBuffer holds help destined for a terminal, to be shown through a
+// pager if it is too long.
+type pageBuffer struct {
+	bytes.Buffer
+	term io.Writer
+}
+
+// underlyingWriter returns the writer to which w ultimately sends its
+// output, so that w can be checked for being a terminal.
+func underlyingWriter(w io.Writer) io.Writer {
+	switch v := w.(type) {
+	case exitOnBrokenPipe:
+		return underlyingWriter(v.w)
+	case *pageBuffer:
+		return underlyingWriter(v.term)
+	}
+	return w
+}
+
+// pageHelp calls write to write help to r's standard output. If r.PageHelp is
+// set, standard output is a terminal, and the help has more lines than the
+// terminal does, the help is shown through a pager.
+func (r *Runner) pageHelp(write func(w io.Writer)) {
+	out := r.Stdout()
+	if !r.PageHelp || !isTerminal(out) {
+		write(out)
+		return
+	}
+	buf := &pageBuffer{term: out}
+	write(buf)
+	height := terminalHeight(out)
+	if height == 0 || bytes.Count(buf.Bytes(), []byte("\n")) < height {
+		out.Write(buf.Bytes())
+		return
+	}
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = defaultPager
+	}
+	args := strings.Fields(pager)
+	if len(args) == 0 {
+		out.Write(buf.Bytes())
+		return
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = buf
+	cmd.Stdout = out
+	cmd.Stderr = r.Stderr()
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			// The pager couldn't be started.
+			out.Write(buf.Bytes())
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package subcmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// pick lets the user choose one of cmds from a numbered list, if r.Picker
+// is set and r is being used interactively. The list follows heading,
+// which has already been translated by r.Translate. pick
+// returns the name of the chosen command and reports whether the user chose
+// one rather than canceling.
+func (r *Runner) pick(heading string, cmds []Command) (string, bool) {
+	if !r.Picker || len(cmds) == 0 || !r.interactive() {
+		return "", false
+	}
+	w := r.Stderr()
+	fmt.Fprintf(w, "%s\n\n", heading)
+	rows := make([][]string, len(cmds))
+	for i, cmd := range cmds {
+		rows[i] = []string{fmt.Sprintf("%d) %s", i+1, cmd.Name), cmd.description()}
+	}
+	writeColumns(w, rows)
+	in := bufio.NewReader(r.Stdin())
+	for {
+		fmt.Fprintf(w, "\n%s ", r.tr("Command (number or name, or nothing to cancel):"))
+		line, err := in.ReadString('\n')
+		choice := strings.TrimSpace(line)
+		if choice == "" {
+			if err == nil {
+				return "", false
+			}
+			// Finish the prompt's line at the end of the input.
+			fmt.Fprintln(w)
+			return "", false
+		}
+		if n, err := strconv.Atoi(choice); err == nil && 1 <= n && n <= len(cmds) {
+			return cmds[n-1].Name, true
+		}
+		for _, cmd := range cmds {
+			if r.sameName(cmd.Name, choice) {
+				return cmd.Name, true
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(w)
+			return "", false
+		}
+		fmt.Fprintf(w, r.tr("%s is not one of the choices")+"\n", quoteArg(choice))
+	}
+}
+
+// interactive reports whether r's standard input and standard error are
+// both terminals.
+func (r *Runner) interactive() bool {
+	in, ok := r.Stdin().(*os.File)
+	return ok && isTerminal(in) && isTerminal(r.Stderr())
+}
+
+// similarCommands returns the commands suggested in place of name, which
+// isn't a command.
+func (r *Runner) similarCommands(name string) []Command {
+	var cmds []Command
+	for _, s := range r.suggestions(name) {
+		if cmd, ok := r.lookup(s); ok {
+			cmds = append(cmds, *cmd)
+		}
+	}
+	return cmds
+}
@@ -0,0 +1,100 @@
+package subcmd
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// pluginOrigin is the Origin of the commands provided by plugins.
+const pluginOrigin = "plugins"
+
+// pluginCommands returns a command for each plugin found on PATH: an
+// executable file called prog-foo provides the command foo for the program
+// prog. (The plugins for the Subcommands of prog's command bar are called
+// prog-bar-foo.) If several directories hold a plugin with the same name, the
+// one earliest in PATH is used. The plugins are named after the base name
+// of the program, even if the runner is named by the path of its executable.
+func (r *Runner) pluginCommands() []Command {
+	prefix := strings.Replace(r.baseName(), " ", "-", -1) + "-"
+	found := make(map[string]string)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		fis, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, fi := range fis {
+			name := fi.Name()
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+				continue
+			}
+			if fi.IsDir() || (runtime.GOOS != "windows" && fi.Mode()&0111 == 0) {
+				continue
+			}
+			name = strings.TrimPrefix(name, prefix)
+			if r.isSubcommandPlugin(name) {
+				continue
+			}
+			if _, ok := found[name]; !ok {
+				found[name] = filepath.Join(dir, fi.Name())
+			}
+		}
+	}
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	cmds := make([]Command, len(names))
+	for i, name := range names {
+		path := found[name]
+		cmd := &cmds[i]
+		*cmd = Command{
+			Name:        name,
+			Description: path,
+			Origin:      pluginOrigin,
+			DoErr: func(args []string) error {
+				return r.runPlugin(path, args, r.errorHandlingFor(cmd))
+			},
+		}
+	}
+	return cmds
+}
+
+// isSubcommandPlugin reports whether name, the name of a plugin for r,
+// belongs instead to the Subcommands of one of r's commands.
+func (r *Runner) isSubcommandPlugin(name string) bool {
+	for _, cmd := range r.cmds {
+		if len(cmd.Subcommands) > 0 && strings.HasPrefix(name, cmd.Name+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// runPlugin runs the plugin at path with args, connecting it to the
+// runner's streams. The plugin's failure is handled according to eh.
+func (r *Runner) runPlugin(path string, args []string, eh flag.ErrorHandling) error {
+	plugin := exec.Command(path, args...)
+	plugin.Stdin = r.Stdin()
+	plugin.Stdout = r.Stdout()
+	plugin.Stderr = r.Stderr()
+	err := plugin.Run()
+	if ee, ok := err.(*exec.ExitError); ok && ee.ExitCode() > 0 && eh == flag.ExitOnError &&
+		!r.chain.fail(ee.ExitCode()) {
+		// The plugin has reported its own failure.
+		r.Exit(ee.ExitCode())
+	}
+	return err
+}
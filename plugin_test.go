@@ -0,0 +1,27 @@
+package subcmd
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPluginCommandsPathName(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("plugin scripts need a Unix shell")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "prog-kv"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	for _, name := range []string{"prog", "./prog", "/opt/bin/prog"} {
+		r := New(name, nil, flag.ContinueOnError)
+		cmds := r.pluginCommands()
+		if len(cmds) != 1 || cmds[0].Name != "kv" {
+			t.Errorf("plugins of %q: got %v; want the kv command", name, cmds)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package subcmd
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+// recoverPanic, when deferred by a function that runs a command, turns a
+// panic by the command into an error, which it stores in *err. The stack
+// trace of the panic is printed to r's standard error if the GOTRACEBACK
+// environment variable is set (to anything but "none").
+func (r *Runner) recoverPanic(err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if tb := os.Getenv("GOTRACEBACK"); tb != "" && tb != "none" {
+		fmt.Fprintf(r.Stderr(), "panic: %v\n\n%s\n", v, debug.Stack())
+	}
+	*err = fmt.Errorf("panic: %v", v)
+}
@@ -0,0 +1,87 @@
+package subcmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+)
+
+type serveRequest struct {
+	ID      json.RawMessage `json:"id,omitempty"`
+	Command string          `json:"command"`
+	Args    []string        `json:"args"`
+}
+
+type serveResponse struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Stdout string          `json:"stdout"`
+	Stderr string          `json:"stderr"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// ServeJSON runs commands as directed by a stream of JSON requests read from
+// in, writing a JSON response to out for each one. This allows other programs,
+// such as editors, to drive the program without parsing its human-oriented
+// output.
+//
+// A request names a command and its arguments, and may include an ID of any
+// JSON type, which is copied to the response:
+//
+//	{"id": 1, "command": "foo", "args": ["-n", "3"]}
+//
+// The response holds whatever the command wrote to the runner's standard
+// output and standard error streams (which are replaced for each request, as
+// r.Stdout, r.Stderr, and the Stdout and Stderr of the command's context
+// show) along with any error from dispatching the command:
+//
+//	{"id": 1, "stdout": "n: 3\n", "stderr": ""}
+//	{"id": 2, "stdout": "", "stderr": "", "error": "prog: no such command \"fo\""}
+//
+// Output written directly to os.Stdout or os.Stderr isn't captured. Errors
+// are always reported in the response, regardless of the runner's
+// error-handling behavior; however, a command that calls os.Exit ends the
+// program. ServeJSON returns nil when in is exhausted, or an error if in
+// contains malformed JSON or writing to out fails.
+func (r *Runner) ServeJSON(in io.Reader, out io.Writer) error {
+	defer func(streams Streams) { r.Streams = streams }(r.Streams)
+	rr := *r
+	rr.errorHandling = flag.ContinueOnError
+	if r.isDefaultUsage() {
+		// The default Usage writes to the streams of the runner for
+		// which it was made.
+		rr.Usage = rr.defaultUsage
+	}
+	dec := json.NewDecoder(in)
+	enc := json.NewEncoder(out)
+	for {
+		var req serveRequest
+		if err := dec.Decode(&req); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		var stdout, stderr bytes.Buffer
+		// Commands may write to the streams of r, rather than rr,
+		// through r.Stdout and r.Stderr.
+		r.Streams.Out, r.Streams.Err = &stdout, &stderr
+		rr.Streams = r.Streams
+		args := append([]string{req.Command}, req.Args...)
+		err := rr.Run(args)
+		if err == ErrHelp {
+			// Help that was asked for goes to standard output.
+			rr.helpOut = &stdout
+			rr.Usage()
+			rr.helpOut = nil
+			err = nil
+		}
+		resp := serveResponse{ID: req.ID, Stdout: stdout.String(), Stderr: stderr.String()}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+}
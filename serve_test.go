@@ -0,0 +1,67 @@
+package subcmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestServeJSONHelp(t *testing.T) {
+	cmds := []Command{{Name: "foo", Description: "do foo", Do: func([]string) {}}}
+	r := New("prog", cmds, flag.ExitOnError)
+	var side bytes.Buffer
+	r.Streams.Out = &side
+	r.Streams.Err = &side
+	var out bytes.Buffer
+	if err := r.ServeJSON(strings.NewReader(`{"command": "help"}`), &out); err != nil {
+		t.Fatal(err)
+	}
+	var resp serveResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.Stdout, "do foo") {
+		t.Errorf("response stdout is %q; want the usage", resp.Stdout)
+	}
+	if side.Len() > 0 {
+		t.Errorf("usage went to the runner's streams: %q", side.String())
+	}
+}
+
+func TestServeJSONStreams(t *testing.T) {
+	var r *Runner
+	cmds := []Command{
+		{Name: "ctx", DoContext: func(ctx context.Context, args []string) error {
+			fmt.Fprintln(Stdout(ctx), "out")
+			fmt.Fprintln(Stderr(ctx), "err")
+			return nil
+		}},
+		{Name: "runner", Do: func([]string) { fmt.Fprintln(r.Stdout(), "runner out") }},
+	}
+	r = New("prog", cmds, flag.ExitOnError)
+	var out bytes.Buffer
+	in := `{"id": 1, "command": "ctx"} {"id": 2, "command": "runner"}`
+	if err := r.ServeJSON(strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(&out)
+	for _, want := range []serveResponse{
+		{Stdout: "out\n", Stderr: "err\n"},
+		{Stdout: "runner out\n"},
+	} {
+		var resp serveResponse
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Stdout != want.Stdout || resp.Stderr != want.Stderr || resp.Error != "" {
+			t.Errorf("got response %+v; want %+v", resp, want)
+		}
+	}
+	if r.Streams.Out != nil || r.Streams.Err != nil {
+		t.Errorf("ServeJSON left the runner's streams set to %+v", r.Streams)
+	}
+}
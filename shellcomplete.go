@@ -0,0 +1,249 @@
+package subcmd
+
+import (
+	"io"
+	"strings"
+	"text/template"
+)
+
+// GenBashCompletion writes a bash completion script for the program to w.
+// The script asks the program for completions (by way of the hidden
+// __complete command), so it completes the names of the commands and their
+// Subcommands, as well as any arguments completed by the commands
+// themselves, without needing to be regenerated when the commands change.
+//
+// The script may be sourced by a user's ~/.bashrc or installed in a
+// directory such as /usr/share/bash-completion/completions.
+func (r *Runner) GenBashCompletion(w io.Writer) error {
+	return bashCompletion.Execute(w, r.scriptData())
+}
+
+var bashCompletion = template.Must(template.New("bash").Parse(`# bash completion for {{.Name}}
+
+_{{.Func}}_complete() {
+	local IFS=$'\n'
+	COMPREPLY=($("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+
+complete -o default -F _{{.Func}}_complete {{.Name}}
+`))
+
+// GenZshCompletion writes a zsh completion script for the program to w.
+// The script lists the commands and their Subcommands along with their
+// descriptions, in the manner of _describe, and asks the program for
+// completions of the commands' arguments (by way of the hidden __complete
+// command). The script must be regenerated when the commands change.
+//
+// The script may be installed as a file named "_prog" (for a program called
+// prog) in a directory in the user's $fpath, or sourced by the user's
+// ~/.zshrc after compinit.
+func (r *Runner) GenZshCompletion(w io.Writer) error {
+	data := r.scriptData()
+	ew := &errWriter{w: w}
+	ew.printf("#compdef %s\n\n", data.Name)
+	ew.printf("_%s() {\n", data.Func)
+	ew.printf("\tlocal -a completions\n")
+	ew.printf("\tcase \"${(j: :)words[2,CURRENT-1]}\" in\n")
+	for _, g := range r.completionGroups() {
+		ew.printf("\t%s)\n\t\tcompletions=(\n", shellQuote(g.path))
+		for _, cmd := range g.cmds {
+			desc := strings.Replace(cmd.Name, ":", "\\:", -1)
+			if d := cmd.description(); d != "" {
+				desc += ":" + d
+			}
+			ew.printf("\t\t\t%s\n", shellQuote(desc))
+		}
+		ew.printf("\t\t)\n\t\t_describe command completions\n\t\t;;\n")
+	}
+	ew.printf("\t*)\n")
+	ew.printf("\t\tcompletions=(${(f)\"$(\"${words[1]}\" __complete \"${(@)words[2,CURRENT]}\" 2>/dev/null)\"})\n")
+	ew.printf("\t\tcompadd -a completions\n")
+	ew.printf("\t\t;;\n")
+	ew.printf("\tesac\n}\n\n")
+	// Work both when autoloaded from $fpath and when sourced.
+	ew.printf("if [ \"$funcstack[1]\" = \"_%s\" ]; then\n", data.Func)
+	ew.printf("\t_%s \"$@\"\nelse\n\tcompdef _%[1]s %s\nfi\n", data.Func, data.Name)
+	return ew.err
+}
+
+// GenFishCompletion writes a fish completion script for the program to w.
+// The script lists the commands and their Subcommands along with their
+// descriptions, and asks the program for completions of the commands'
+// arguments (by way of the hidden __complete command). The script must be
+// regenerated when the commands change.
+//
+// The script may be installed as a file named "prog.fish" (for a program
+// called prog) in ~/.config/fish/completions.
+func (r *Runner) GenFishCompletion(w io.Writer) error {
+	data := r.scriptData()
+	groups := r.completionGroups()
+	ew := &errWriter{w: w}
+	ew.printf("# fish completion for %s\n\n", data.Name)
+	// __prog_at reports whether the words following the program's name
+	// are its arguments.
+	ew.printf("function __%s_at\n", data.Func)
+	ew.printf("    set -l words (commandline -opc)\n")
+	ew.printf("    set -e words[1]\n")
+	ew.printf("    test \"$words\" = \"$argv\"\n")
+	ew.printf("end\n\n")
+	// __prog_args prints the completions of a command's arguments.
+	ew.printf("function __%s_args\n", data.Func)
+	ew.printf("    set -l words (commandline -opc)\n")
+	ew.printf("    set -l prog $words[1]\n")
+	ew.printf("    set -e words[1]\n")
+	ew.printf("    switch \"$words\"\n")
+	ew.printf("        case")
+	for _, g := range groups {
+		ew.printf(" %s", fishQuote(g.path))
+	}
+	ew.printf("\n            return\n")
+	ew.printf("    end\n")
+	ew.printf("    $prog __complete $words (commandline -ct) 2>/dev/null\n")
+	ew.printf("end\n\n")
+	ew.printf("complete -c %s -a '(__%s_args)'\n", data.Name, data.Func)
+	for _, g := range groups {
+		cond := "__" + data.Func + "_at"
+		if g.path != "" {
+			cond += " " + g.path
+		}
+		for _, cmd := range g.cmds {
+			ew.printf("complete -c %s -f -n %s -a %s", data.Name, fishQuote(cond), fishQuote(cmd.Name))
+			if d := cmd.description(); d != "" {
+				ew.printf(" -d %s", fishQuote(d))
+			}
+			ew.printf("\n")
+		}
+	}
+	return ew.err
+}
+
+// fishQuote quotes s for fish using single quotes.
+func fishQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return "'" + strings.Replace(s, "'", `\'`, -1) + "'"
+}
+
+// GenPowerShellCompletion writes a PowerShell completion script for the
+// program to w. The script asks the program for completions (by way of the
+// hidden __complete command), and it shows the descriptions of the commands
+// and their Subcommands as tooltips.
+//
+// The script may be dot-sourced by the user's PowerShell profile.
+func (r *Runner) GenPowerShellCompletion(w io.Writer) error {
+	data := r.scriptData()
+	ew := &errWriter{w: w}
+	ew.printf("# powershell completion for %s\n\n", data.Name)
+	ew.printf("Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n", psQuote(data.Name))
+	ew.printf("    param($wordToComplete, $commandAst, $cursorPosition)\n")
+	ew.printf("    $descriptions = @{\n")
+	for _, g := range r.completionGroups() {
+		for _, cmd := range g.cmds {
+			if d := cmd.description(); d != "" {
+				key := strings.TrimPrefix(g.path+" "+cmd.Name, " ")
+				ew.printf("        %s = %s\n", psQuote(key), psQuote(d))
+			}
+		}
+	}
+	ew.printf("    }\n")
+	ew.printf("    $words = @($commandAst.CommandElements |\n")
+	ew.printf("        Where-Object { $_.Extent.StartOffset -lt $cursorPosition } |\n")
+	ew.printf("        ForEach-Object { $_.ToString() })\n")
+	ew.printf("    $program = $words[0]\n")
+	ew.printf("    $rest = @($words | Select-Object -Skip 1)\n")
+	ew.printf("    $path = $rest\n")
+	ew.printf("    if ($wordToComplete -eq '') {\n")
+	ew.printf("        # Older versions of PowerShell drop empty arguments to native commands.\n")
+	ew.printf("        if ($PSVersionTable.PSVersion -lt [version]'7.3') { $rest += '\"\"' } else { $rest += '' }\n")
+	ew.printf("    } else {\n")
+	ew.printf("        $path = @($rest | Select-Object -SkipLast 1)\n")
+	ew.printf("    }\n")
+	ew.printf("    & $program __complete @rest 2>$null | ForEach-Object {\n")
+	ew.printf("        $tooltip = $descriptions[(@($path) + $_) -join ' ']\n")
+	ew.printf("        if (-not $tooltip) { $tooltip = $_ }\n")
+	ew.printf("        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $tooltip)\n")
+	ew.printf("    }\n")
+	ew.printf("}\n")
+	return ew.err
+}
+
+// psQuote quotes s for PowerShell using single quotes.
+func psQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// A completionGroup is a list of commands that may follow the words of path
+// (the names of the commands leading to them) on the command line.
+type completionGroup struct {
+	path string
+	cmds []Command
+}
+
+// completionGroups returns the completionGroup for r's commands and for the
+// Subcommands of each of them, recursively.
+func (r *Runner) completionGroups() []completionGroup {
+	var groups []completionGroup
+	var walk func(r *Runner, path string)
+	walk = func(r *Runner, path string) {
+		cmds := r.visibleCommands()
+		groups = append(groups, completionGroup{path: path, cmds: cmds})
+		for i := range cmds {
+			if len(cmds[i].Subcommands) > 0 {
+				walk(r.child(&cmds[i]), strings.TrimPrefix(path+" "+cmds[i].Name, " "))
+			}
+		}
+	}
+	walk(r, "")
+	return groups
+}
+
+// shellQuote quotes s for the shell using single quotes.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// scriptData is the data given to the templates of the completion scripts.
+type scriptData struct {
+	Name string // the program's name, as by programName (without any directory)
+	Func string // the program's name, made suitable for shell identifiers
+}
+
+func (r *Runner) scriptData() scriptData {
+	name := r.programName()
+	return scriptData{
+		Name: name,
+		Func: strings.Map(func(c rune) rune {
+			if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+				return c
+			}
+			return '_'
+		}, name),
+	}
+}
+
+// completionCommand is the name of the command enabled by
+// Runner.CompletionCommand.
+const completionCommand = "completion"
+
+// completionCommand returns the command that prints completion scripts.
+func (r *Runner) completionCommand() Command {
+	gens := map[string]func(io.Writer) error{
+		"bash":       r.GenBashCompletion,
+		"zsh":        r.GenZshCompletion,
+		"fish":       r.GenFishCompletion,
+		"powershell": r.GenPowerShellCompletion,
+	}
+	name := r.programName()
+	return Command{
+		Name:        completionCommand,
+		Description: "print a shell completion script",
+		Args:        []Arg{{Name: "SHELL", Choices: []string{"bash", "zsh", "fish", "powershell"}}},
+		NArgs:       ExactArgs(1),
+		Hidden:      true,
+		Long: "The script completes the program's commands and their arguments.\n" +
+			"For example, to install it:\n\n" +
+			"  " + name + " completion bash > /etc/bash_completion.d/" + name + "\n" +
+			"  " + name + " completion zsh > \"${fpath[1]}/_" + name + "\"\n" +
+			"  " + name + " completion fish > ~/.config/fish/completions/" + name + ".fish",
+		DoErr: func(args []string) error { return gens[args[0]](r.Stdout()) },
+	}
+}
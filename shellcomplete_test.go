@@ -0,0 +1,26 @@
+package subcmd
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompletionScriptsPathName(t *testing.T) {
+	r := New("/usr/local/bin/prog", []Command{{Name: "add", Do: func([]string) {}}}, flag.ContinueOnError)
+	for shell, gen := range map[string]func(io.Writer) error{
+		"bash":       r.GenBashCompletion,
+		"zsh":        r.GenZshCompletion,
+		"fish":       r.GenFishCompletion,
+		"powershell": r.GenPowerShellCompletion,
+	} {
+		var b strings.Builder
+		if err := gen(&b); err != nil {
+			t.Fatal(err)
+		}
+		if s := b.String(); strings.Contains(s, "usr") || !strings.Contains(s, "prog") {
+			t.Errorf("%s script doesn't name the program by its base name:\n%s", shell, s)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package subcmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// ErrNoShellWrapper is returned by ShellEval if the program was not run
+// through the shell function written by GenShellWrapper.
+var ErrNoShellWrapper = errors.New("not run through the shell wrapper")
+
+// GenShellWrapper writes a shell function for the program to w, for the
+// given shell (bash, zsh, or fish). The function has the same name as the
+// program and runs it as usual, except that the shell commands passed to
+// ShellEval by the commands with ChangesShell set are afterwards run by the
+// calling shell itself. This lets a command change the shell's working
+// directory or environment, which a program cannot do on its own:
+//
+//	{
+//		Name:         "go",
+//		Description:  "change to a project's directory",
+//		ChangesShell: true,
+//		DoErr: func(args []string) error {
+//			return r.ShellEval("cd " + subcmd.ShellQuote(projectDir(args[0])))
+//		},
+//	}
+//
+// Users install the function by adding a line such as
+//
+//	eval "$(prog shell-wrapper bash)"
+//
+// to their ~/.bashrc (for a program that prints the function from a command
+// of that name), or the equivalent for their shell. The function only
+// passes through the shell for the commands with ChangesShell set (or with
+// Subcommands that have it set), so it must be regenerated when those
+// change.
+func (r *Runner) GenShellWrapper(w io.Writer, shell string) error {
+	var tmpl *template.Template
+	switch shell {
+	case "bash", "zsh":
+		tmpl = shWrapper
+	case "fish":
+		tmpl = fishWrapper
+	default:
+		return fmt.Errorf("subcmd: unsupported shell %q for the shell wrapper", shell)
+	}
+	var patterns []string
+	for _, name := range r.shellChangingNames() {
+		patterns = append(patterns, shellQuote(name))
+	}
+	data := struct {
+		scriptData
+		Env      string
+		Patterns []string
+	}{r.scriptData(), r.shellEvalEnv(), patterns}
+	return tmpl.Execute(w, data)
+}
+
+var shWrapper = template.Must(template.New("sh").Parse(`# shell wrapper for {{.Name}}
+
+{{.Name}}() {
+	case "$1" in
+	-*{{range .Patterns}}|{{.}}{{end}}) ;;
+	*)
+		command {{.Name}} "$@"
+		return
+		;;
+	esac
+	local __{{.Func}}_eval __{{.Func}}_status
+	__{{.Func}}_eval="$(mktemp)" || return
+	{{.Env}}="$__{{.Func}}_eval" command {{.Name}} "$@"
+	__{{.Func}}_status=$?
+	. "$__{{.Func}}_eval"
+	rm -f "$__{{.Func}}_eval"
+	return $__{{.Func}}_status
+}
+`))
+
+var fishWrapper = template.Must(template.New("fish").Parse(`# shell wrapper for {{.Name}}
+
+function {{.Name}} --wraps {{.Name}}
+	switch "$argv[1]"
+	case '-*'{{range .Patterns}} {{.}}{{end}}
+	case '*'
+		command {{.Name}} $argv
+		return
+	end
+	set -l file (mktemp); or return
+	env {{.Env}}=$file {{.Name}} $argv
+	set -l st $status
+	source $file
+	rm -f $file
+	return $st
+end
+`))
+
+// shellChangingNames returns the names and aliases of r's commands that
+// have ChangesShell set or that have Subcommands with it set.
+func (r *Runner) shellChangingNames() []string {
+	var names []string
+	for i := range r.cmds {
+		if changesShell(&r.cmds[i]) {
+			names = append(names, r.cmds[i].Name)
+			names = append(names, r.cmds[i].Aliases...)
+		}
+	}
+	return names
+}
+
+func changesShell(cmd *Command) bool {
+	if cmd.ChangesShell {
+		return true
+	}
+	for i := range cmd.Subcommands {
+		if changesShell(&cmd.Subcommands[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// shellEvalEnv returns the name of the environment variable through which
+// the shell wrapper passes the file of shell commands to the program. It is
+// specific to the program so that other programs that it runs don't use the
+// file.
+func (r *Runner) shellEvalEnv() string {
+	return strings.ToUpper(r.scriptData().Func) + "_SHELL_EVAL"
+}
+
+// ShellEval arranges for the shell function written by GenShellWrapper to
+// run script, a shell command (or several, on separate lines), in the
+// calling shell once the program exits. It returns ErrNoShellWrapper if
+// the program was not run through the function. Any arguments in script
+// must be quoted, as by ShellQuote.
+func (r *Runner) ShellEval(script string) error {
+	path := os.Getenv(r.shellEvalEnv())
+	if path == "" {
+		return ErrNoShellWrapper
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, script+"\n")
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// ShellQuote quotes s as a single argument for the shells supported by
+// GenShellWrapper.
+func ShellQuote(s string) string {
+	return shellQuote(s)
+}
@@ -0,0 +1,25 @@
+package subcmd
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestShellWrapperPathName(t *testing.T) {
+	cmds := []Command{{Name: "cd", ChangesShell: true, Do: func([]string) {}}}
+	r := New("/usr/local/bin/prog", cmds, flag.ContinueOnError)
+	for _, shell := range []string{"bash", "fish"} {
+		var b strings.Builder
+		if err := r.GenShellWrapper(&b, shell); err != nil {
+			t.Fatal(err)
+		}
+		s := b.String()
+		if strings.Contains(s, "usr") || !strings.Contains(s, "PROG_SHELL_EVAL") {
+			t.Errorf("%s wrapper doesn't name the program by its base name:\n%s", shell, s)
+		}
+	}
+	if got := r.shellEvalEnv(); got != "PROG_SHELL_EVAL" {
+		t.Errorf("shellEvalEnv: got %q; want PROG_SHELL_EVAL", got)
+	}
+}
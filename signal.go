@@ -0,0 +1,68 @@
+package subcmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// handleSignals returns a context derived from ctx that is canceled when the
+// program receives SIGINT or SIGTERM, along with a function that stops
+// handling the signals and reports which one was received, if any. A second
+// signal exits the program at once with the conventional status for the
+// signal (128 plus its number).
+func (r *Runner) handleSignals(ctx context.Context) (context.Context, func() os.Signal) {
+	ctx, cancel := context.WithCancel(ctx)
+	c := make(chan os.Signal, 2)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	received := make(chan os.Signal, 1)
+	go func() {
+		select {
+		case sig := <-c:
+			received <- sig
+			cancel()
+		case <-done:
+			return
+		}
+		select {
+		case sig := <-c:
+			r.Exit(signalExitCode(sig))
+		case <-done:
+		}
+	}()
+	stop := func() os.Signal {
+		signal.Stop(c)
+		close(done)
+		cancel()
+		select {
+		case sig := <-received:
+			return sig
+		default:
+			return nil
+		}
+	}
+	return ctx, stop
+}
+
+// signalExitCode returns the exit status of a program killed by sig, one of
+// the signals handled by handleSignals.
+func signalExitCode(sig os.Signal) int {
+	if sig == os.Interrupt {
+		return 130 // 128+SIGINT
+	}
+	return 143 // 128+SIGTERM
+}
+
+// interruptedError returns the error reported when cmd stops after the
+// program receives sig.
+func (r *Runner) interruptedError(cmd *Command, sig os.Signal) error {
+	msg := sig.String()
+	if sig == os.Interrupt {
+		msg = "interrupted"
+	}
+	err := r.wrapCommandError(cmd, errors.New(msg))
+	return WithExitCode(err, signalExitCode(sig))
+}
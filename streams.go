@@ -0,0 +1,99 @@
+package subcmd
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+)
+
+// Streams are a set of standard I/O streams. A nil field stands for the
+// corresponding stream of the process (os.Stdin, os.Stdout, or os.Stderr).
+type Streams struct {
+	In  io.Reader
+	Out io.Writer
+	Err io.Writer
+}
+
+// Stdin returns the runner's standard input stream, r.Streams.In, or
+// os.Stdin if that is nil.
+func (r *Runner) Stdin() io.Reader {
+	if r.Streams.In != nil {
+		return r.Streams.In
+	}
+	return os.Stdin
+}
+
+// Stdout returns the runner's standard output stream, r.Streams.Out, or
+// os.Stdout if that is nil.
+//
+// If the runner's error-handling behavior is ExitOnError, writes to the
+// returned stream that fail because of a broken pipe (for instance, because
+// the output was piped into head(1)) quietly exit the program with status
+// 141, as if it had been killed by SIGPIPE.
+func (r *Runner) Stdout() io.Writer {
+	w := r.Streams.Out
+	if w == nil {
+		w = os.Stdout
+	}
+	return r.wrapOutput(w)
+}
+
+// Stderr returns the runner's standard error stream, r.Streams.Err, or
+// os.Stderr if that is nil. Broken pipes are handled as for Stdout.
+func (r *Runner) Stderr() io.Writer {
+	w := r.Streams.Err
+	if w == nil {
+		w = os.Stderr
+	}
+	return r.wrapOutput(w)
+}
+
+func (r *Runner) wrapOutput(w io.Writer) io.Writer {
+	if r.errorHandling != flag.ExitOnError {
+		return w
+	}
+	return exitOnBrokenPipe{w, r.Exit}
+}
+
+// streamsKey is the context key for the Streams given to a command.
+type streamsKey struct{}
+
+// withStreams returns a context derived from ctx that carries r's streams,
+// for a command to get with Stdin, Stdout, and Stderr.
+func (r *Runner) withStreams(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamsKey{}, Streams{
+		In:  r.Stdin(),
+		Out: r.Stdout(),
+		Err: r.Stderr(),
+	})
+}
+
+// Stdin returns the standard input stream of the Runner that is running the
+// command to which ctx was given (as by DoContext or Middleware), or
+// os.Stdin if ctx didn't come from a Runner.
+func Stdin(ctx context.Context) io.Reader {
+	if s, ok := ctx.Value(streamsKey{}).(Streams); ok {
+		return s.In
+	}
+	return os.Stdin
+}
+
+// Stdout returns the standard output stream of the Runner that is running
+// the command to which ctx was given, as by Runner.Stdout, or os.Stdout if
+// ctx didn't come from a Runner. A command that writes its output there,
+// rather than to os.Stdout, can be run by ServeJSON and subcmdtest.
+func Stdout(ctx context.Context) io.Writer {
+	if s, ok := ctx.Value(streamsKey{}).(Streams); ok {
+		return s.Out
+	}
+	return os.Stdout
+}
+
+// Stderr is like Stdout, but returns the standard error stream.
+func Stderr(ctx context.Context) io.Writer {
+	if s, ok := ctx.Value(streamsKey{}).(Streams); ok {
+		return s.Err
+	}
+	return os.Stderr
+}
@@ -0,0 +1,162 @@
+package subcmd
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Commands returns the commands declared by v, which must be a pointer to a
+// struct. Each field of the struct with a "cmd" tag declares a command; the
+// tag gives the command's name, or, if it is empty, the command is named for
+// the field in lower case. These tags on the field provide further details:
+//
+//	help     the command's description
+//	args     the command's ArgsUsage
+//	aliases  a comma-separated list of the command's Aliases
+//
+// The field's type must be a struct (or a pointer to one, which Commands
+// allocates if it is nil). The fields of that struct with a "flag" tag are the
+// command's flags: the tag gives the flag's name, the "help" tag gives its
+// usage message, and the "default" tag gives its default value (otherwise,
+// the default is the field's value when Commands is called). A flag field must
+// be a string, bool, int, int64, uint, uint64, float64, or time.Duration, or
+// implement flag.Value. If the struct itself has fields with "cmd" tags, they
+// are the command's Subcommands; otherwise, a pointer to the struct must have
+// a method
+//
+//	Run(args []string) error
+//
+// which implements the command. Run parses the command's flags, setting the
+// struct's fields, before calling it with the remaining arguments.
+//
+// For example, these declarations describe a program with a command
+// "serve" that has a flag -addr:
+//
+//	type serveCmd struct {
+//		Addr string `flag:"addr" help:"listen on this address" default:":8080"`
+//	}
+//
+//	func (c *serveCmd) Run(args []string) error { ... }
+//
+//	var cli struct {
+//		Serve serveCmd `cmd:"serve" help:"run the server"`
+//	}
+//
+//	r := subcmd.New("prog", subcmd.Commands(&cli), flag.ExitOnError)
+//
+// Commands panics if v doesn't declare its commands correctly.
+func Commands(v interface{}) []Command {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		panicf("subcmd: Commands requires a pointer to a struct, not %T", v)
+	}
+	return structCommands(rv.Elem())
+}
+
+// commandRunner is implemented by the command structs given to Commands.
+type commandRunner interface {
+	Run(args []string) error
+}
+
+var flagValueType = reflect.TypeOf((*flag.Value)(nil)).Elem()
+
+// structCommands returns the commands declared by the fields of the struct
+// value v.
+func structCommands(v reflect.Value) []Command {
+	var cmds []Command
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := field.Tag.Lookup("cmd")
+		if !ok {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() != reflect.Struct {
+			panicf("subcmd: command field %s must be a struct or a pointer to a struct", field.Name)
+		}
+		cmd := Command{
+			Name:        name,
+			Description: field.Tag.Get("help"),
+			ArgsUsage:   field.Tag.Get("args"),
+		}
+		if aliases := field.Tag.Get("aliases"); aliases != "" {
+			cmd.Aliases = strings.Split(aliases, ",")
+		}
+		cmd.Flags = structFlags(name, fv)
+		if subcmds := structCommands(fv); len(subcmds) > 0 {
+			cmd.Subcommands = subcmds
+		} else {
+			runner, ok := fv.Addr().Interface().(commandRunner)
+			if !ok {
+				panicf("subcmd: *%s, the type of command %q, has no Run method", fv.Type(), name)
+			}
+			cmd.DoErr = runner.Run
+		}
+		if !hasFlags(cmd.Flags) {
+			cmd.Flags = nil
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+// structFlags returns a FlagSet holding the flags declared by the fields of
+// the struct value v, which belongs to the command called name.
+func structFlags(name string, v reflect.Value) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		flagName, ok := field.Tag.Lookup("flag")
+		if !ok {
+			continue
+		}
+		if flagName == "" {
+			flagName = strings.ToLower(field.Name)
+		}
+		usage := field.Tag.Get("help")
+		p := v.Field(i).Addr().Interface()
+		switch p := p.(type) {
+		case flag.Value:
+			fs.Var(p, flagName, usage)
+		case *string:
+			fs.StringVar(p, flagName, *p, usage)
+		case *bool:
+			fs.BoolVar(p, flagName, *p, usage)
+		case *int:
+			fs.IntVar(p, flagName, *p, usage)
+		case *int64:
+			fs.Int64Var(p, flagName, *p, usage)
+		case *uint:
+			fs.UintVar(p, flagName, *p, usage)
+		case *uint64:
+			fs.Uint64Var(p, flagName, *p, usage)
+		case *float64:
+			fs.Float64Var(p, flagName, *p, usage)
+		case *time.Duration:
+			fs.DurationVar(p, flagName, *p, usage)
+		default:
+			panicf("subcmd: flag field %s has unsupported type %s", field.Name, field.Type)
+		}
+		if def, ok := field.Tag.Lookup("default"); ok {
+			f := fs.Lookup(flagName)
+			if err := f.Value.Set(def); err != nil {
+				panicf("subcmd: bad default %q for flag field %s: %s", def, field.Name, err)
+			}
+			f.DefValue = def
+		}
+	}
+	return fs
+}
@@ -3,95 +3,1386 @@
 package subcmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
 	"os"
-	"text/tabwriter"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // A Command specifies a sub-command for a program's command-line interface.
 type Command struct {
 	Name        string              // the command's one-word name
+	Aliases     []string            // other names by which the command may be run (optional)
 	Description string              // a short description of the command
+	ArgsUsage   string              // synopsis of the arguments (e.g., "<src> <dst>")
+	Args        []Arg               // the positional arguments, for validation (optional)
+	NArgs       ArgsValidator       // checks the number of positional arguments (optional)
 	Do          func(args []string) // command implementation
+	Complete    CompletionFunc      // completes the arguments (optional)
+	Flags       *flag.FlagSet       // the command's flags, which Run parses before calling Do (optional)
+	MinVersion  string              // the earliest program version offering the command (optional)
+	Stability   Stability           // how settled the command's interface is
+	Hidden      bool                // omit the command from usage and completions
+	Deprecated  string              // if non-empty, why the command is deprecated (shown when it is run)
+	ReplacedBy  string              // the command to use instead of a deprecated one (optional)
+	RemovedIn   string              // the version that will remove a deprecated command (optional)
+	Origin      string              // where the command comes from, if not the program itself (e.g., "plugin kv")
+	Category    string              // the heading under which the command is listed (e.g., "Plumbing commands")
+	Timeout     time.Duration       // the longest the command may run, as for Runner.TimeoutEnv (optional)
+	SeeAlso     []string            // the names of related commands, listed at the end of the command's help (optional)
+
+	// DescriptionFunc, if non-nil, is called to get the command's
+	// description in place of Description. It allows the description to
+	// reflect the program's state at the time help is shown.
+	DescriptionFunc func() string
+
+	// Long is the command's full documentation, which may consist of
+	// several paragraphs. It is shown, following the description, in the
+	// help message for the command (as in "prog help foo").
+	Long string
+
+	// Examples are example invocations of the command, shown at the end
+	// of its help message and in the documentation generated for it.
+	Examples []Example
+
+	// RequiredFlags are the names of flags in Flags that must be given
+	// whenever the command is run. If any are missing, Run reports them
+	// as a usage error instead of running the command. The required flags
+	// are shown without brackets in the command's synopsis.
+	RequiredFlags []string
+
+	// ChangesShell marks a command that changes the state of the shell
+	// from which it is run, such as the shell's working directory, by way
+	// of Runner.ShellEval. Such a command only works when the program is
+	// run through the shell function written by GenShellWrapper.
+	ChangesShell bool
+
+	// DoErr and DoContext are alternatives to Do for implementations that
+	// may fail or that support cancellation. At most one of Do, DoErr, and
+	// DoContext may be set. Run handles an error that they return according
+	// to the runner's error-handling behavior, and RunContext passes its
+	// context to DoContext, which can get the runner's streams from it
+	// with Stdin, Stdout, and Stderr. If the error is or wraps an
+	// ExitCoder, its ExitCode is the program's exit status under
+	// ExitOnError.
+	DoErr     func(args []string) error
+	DoContext func(ctx context.Context, args []string) error
+
+	// ErrorHandling, if non-nil, overrides the runner's error-handling
+	// behavior for the command: it applies when the command fails or is
+	// used incorrectly (such as with an unknown flag). For instance, a
+	// command meant for use in scripts might never exit the program:
+	//
+	//	continueOnError := flag.ContinueOnError
+	//	cmd := subcmd.Command{Name: "query", ErrorHandling: &continueOnError, ...}
+	//
+	// For a command with Subcommands, it is the error-handling behavior of
+	// the subcommand runner.
+	ErrorHandling *flag.ErrorHandling
+
+	// Subcommands, if non-empty, makes the command a group of further
+	// commands rather than a command with an implementation of its own:
+	// running it runs one of the subcommands, as in "prog remote add", by
+	// way of a Runner named for the command (such as "prog remote") that
+	// shares the settings of the parent. The command's Flags are the
+	// subcommand runner's global flags.
+	Subcommands []Command
+}
+
+// An Example is an example invocation of a command.
+type Example struct {
+	Command     string // the full command line (e.g., "prog add -f notes.txt")
+	Description string // what the example does (optional)
+}
+
+// A CommandOrder is an order in which commands are listed.
+type CommandOrder int
+
+// These are the command orders.
+const (
+	// DeclarationOrder lists commands in the order in which they are
+	// declared.
+	DeclarationOrder CommandOrder = iota
+	// NameOrder lists commands alphabetically by name.
+	NameOrder
+	// CategoryOrder lists commands alphabetically by Category and then
+	// by name. The commands without a Category are listed last.
+	CategoryOrder
+)
+
+// sort sorts cmds in the order o.
+func (o CommandOrder) sort(cmds []Command) {
+	switch o {
+	case NameOrder:
+		sort.SliceStable(cmds, func(i, j int) bool {
+			return cmds[i].Name < cmds[j].Name
+		})
+	case CategoryOrder:
+		sort.SliceStable(cmds, func(i, j int) bool {
+			ci, cj := cmds[i].Category, cmds[j].Category
+			if ci != cj {
+				return cj == "" || (ci != "" && ci < cj)
+			}
+			return cmds[i].Name < cmds[j].Name
+		})
+	}
+}
+
+// A Stability describes how settled a command's interface is.
+type Stability int
+
+// These are the stability levels. The zero value is Stable.
+const (
+	Stable Stability = iota // the command is fully supported
+	Beta                    // the command may change in minor ways
+	Alpha                   // the command may change or be removed at any time
+)
+
+func (s Stability) String() string {
+	switch s {
+	case Stable:
+		return "stable"
+	case Beta:
+		return "beta"
+	case Alpha:
+		return "alpha"
+	}
+	return fmt.Sprintf("Stability(%d)", int(s))
+}
+
+// A CommandFunc runs the command called name with the arguments args. It is
+// the form in which Runner.Middleware sees a command. An error returned by a
+// CommandFunc is reported in the same way as one returned by a command's DoErr
+// function.
+type CommandFunc func(ctx context.Context, name string, args []string) error
+
+// run calls the command's implementation.
+func (cmd *Command) run(ctx context.Context, args []string) error {
+	if cmd.DoContext != nil {
+		return cmd.DoContext(ctx, args)
+	}
+	if cmd.DoErr != nil {
+		return cmd.DoErr(args)
+	}
+	cmd.Do(args)
+	return nil
+}
+
+// A commandError is an error returned by a command's DoErr or DoContext
+// function.
+type commandError struct {
+	err error
+}
+
+func (e commandError) Error() string { return e.err.Error() }
+func (e commandError) Unwrap() error { return e.err }
+
+// checkCommands panics if cmds, or the subcommands of any of them, are
+// invalid.
+func checkCommands(cmds []Command) {
+	names := make(map[string]struct{})
+	for _, cmd := range cmds {
+		for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+			if name == completeCommand {
+				panicf("subcmd: cannot name a command %q", name)
+			}
+			if _, ok := names[name]; ok {
+				panicf("subcmd: duplicate command %q given to Run", name)
+			}
+			names[name] = struct{}{}
+		}
+		n := countImplementations(&cmd)
+		if n > 1 {
+			panicf("subcmd: command %q has more than one of Do, DoErr, and DoContext", cmd.Name)
+		}
+		if n > 0 && len(cmd.Subcommands) > 0 {
+			panicf("subcmd: command %q has both an implementation and subcommands", cmd.Name)
+		}
+		checkCommands(cmd.Subcommands)
+	}
+	for _, cmd := range cmds {
+		for _, name := range cmd.RequiredFlags {
+			if cmd.Flags == nil || cmd.Flags.Lookup(name) == nil {
+				panicf("subcmd: command %q requires undefined flag %q", cmd.Name, name)
+			}
+		}
+		for _, name := range cmd.SeeAlso {
+			if _, ok := names[name]; !ok {
+				panicf("subcmd: command %q refers to unknown command %q in SeeAlso", cmd.Name, name)
+			}
+		}
+	}
 }
 
-// A Runner runs sub-commands. To change Usage or ErrorHandling, alter these
-// after creating a runner with New but before calling Runner.Run.
+func countImplementations(cmd *Command) int {
+	var n int
+	if cmd.Do != nil {
+		n++
+	}
+	if cmd.DoErr != nil {
+		n++
+	}
+	if cmd.DoContext != nil {
+		n++
+	}
+	return n
+}
+
+func (cmd *Command) description() string {
+	if cmd.DescriptionFunc != nil {
+		return cmd.DescriptionFunc()
+	}
+	return cmd.Description
+}
+
+// A Runner runs sub-commands. To customize a Runner, alter its exported fields
+// after creating it with New but before calling Runner.Run.
 type Runner struct {
+	name          string
 	cmds          []Command
 	errorHandling flag.ErrorHandling
+	index         commandIndex  // the names and aliases of cmds
+	usage         renderedUsage // the last usage message written by defaultUsage
+	helpOut       io.Writer     // where the usage goes while help is being shown
+	chain         *chainState   // the chain of commands being run with ChainKeepGoing, if any
+	argv          []string      // the command line being run, which Isolate re-executes
 
 	// Usage prints the runner's usage.
 	// If Usage is nil, the package-level Usage is called instead.
+	// The default Usage, set by New, writes to the runner's standard output
+	// when the user asked for help (as with "prog help") and to its
+	// standard error otherwise (as when the usage follows an error). It
+	// keeps the message that it renders for reuse until the runner's
+	// commands are changed by Add or Remove, unless a command has a
+	// DescriptionFunc.
 	Usage func()
+
+	// Flags holds the global flags, which may precede the command name on
+	// the command line. New sets Flags to an empty flag set.
+	Flags *flag.FlagSet
+
+	// ErrorFormat is the format of the errors that the runner prints:
+	// TextErrors (the default) or JSONErrors. The ErrorFormatFlag method
+	// defines a global flag for setting it.
+	ErrorFormat ErrorFormat
+
+	// Streams are the standard I/O streams used by the runner for its own
+	// output, such as usage and error messages. Commands should also use
+	// them (by way of the Stdin, Stdout, and Stderr methods) rather than
+	// using os.Stdin, os.Stdout, and os.Stderr directly, so that the
+	// program can be embedded in another or tested without redirecting the
+	// process's streams.
+	Streams Streams
+
+	// Color controls whether the runner's output, such as its error
+	// messages and (if it uses a TableFormatter) its help, is styled with
+	// ANSI escape sequences. By default (ColorAuto), output is styled if
+	// it is written to a terminal.
+	Color ColorMode
+
+	// Formatter renders the default usage message.
+	// If Formatter is nil, a TableFormatter is used.
+	Formatter Formatter
+
+	// ErrorPrefix, followed by a colon, begins the messages of the errors
+	// that the runner produces (other than ErrHelp), as in
+	// "prog: no such command". New sets ErrorPrefix to the runner's name.
+	// If ErrorPrefix is empty, error messages have no prefix.
+	ErrorPrefix string
+
+	// NoCommand, if non-nil, is called by Run when it is given no
+	// arguments, instead of reporting an error wrapping ErrNoCommand.
+	// For instance, a program that prints its usage and exits successfully
+	// when run without arguments may use
+	//
+	//	r.NoCommand = func() { r.Usage() }
+	NoCommand func()
+
+	// Default, if non-empty, is the name of the command that Run runs (with
+	// no arguments) when it is given no command, as for a program whose
+	// most common action is to show a status. Default takes precedence
+	// over NoCommand.
+	Default string
+
+	// DefaultEnv, if non-empty, names an environment variable (such as
+	// "PROG_DEFAULT_COMMAND") that users may set to the name of the
+	// command to run when none is given. If the variable is set, it takes
+	// precedence over Default.
+	DefaultEnv string
+
+	// HelpExitCode and NoCommandExitCode are the exit statuses used by
+	// Run, when the error-handling behavior is ExitOnError, after printing
+	// help that was requested or printing the usage message because no
+	// command was given, respectively. New sets HelpExitCode to 0 and
+	// NoCommandExitCode to 2. (If NoCommandExitCode is 0, the missing
+	// command is treated as a request for help and no error is printed.)
+	HelpExitCode      int
+	NoCommandExitCode int
+
+	// CommandNotFound, if non-nil, is called by Run with the name of an
+	// unknown command before the error is reported. It may be used to
+	// print a hint, such as how to install a plugin providing the command.
+	CommandNotFound func(name string)
+
+	// Version is the program's version, such as "1.4.2". If Version is set,
+	// commands whose MinVersion is later than Version are omitted from the
+	// usage message and completions, and running them is an error.
+	Version string
+
+	// AlphaFlag, if non-empty, is an argument (such as "--alpha") that must
+	// precede the name of an Alpha command on the command line in order to
+	// run it, acknowledging that the command is unstable. Without it, Run
+	// reports an error.
+	AlphaFlag string
+
+	// Debug enables a hidden "debug" command (unless the runner has a
+	// command by that name) with sub-commands to help diagnose how the
+	// program's commands are set up:
+	//
+	//	debug tree         print every command, including hidden ones
+	//	debug flags CMD    print the flags of the command CMD
+	//	debug json         print the command tree as JSON (see CommandTree)
+	//	debug env          print details of the program's environment
+	Debug bool
+
+	// TimeoutEnv, if non-empty, names an environment variable (such as
+	// "PROG_TIMEOUT") that may be set to a duration, such as "30s", to bound
+	// the running time of the program. If a command is still running when
+	// the duration has elapsed, Run stops waiting for it and handles a
+	// timeout error according to the error-handling behavior: with
+	// ExitOnError, the program prints the error and exits with status 124.
+	// (A command with a DoContext function is given a context with the
+	// corresponding deadline as well; see RunContext.) This lets
+	// automated systems limit any invocation of the program without knowing
+	// the flags of its commands. If a command has a Timeout as well, the
+	// shorter of the two durations applies.
+	TimeoutEnv string
+
+	// Deprecations enables a "deprecations" command (unless the runner has
+	// a command by that name) that lists the deprecated commands, including
+	// the Subcommands of commands, along with their aliases, replacements,
+	// and planned removal versions, so that users can update their scripts
+	// ahead of time.
+	Deprecations bool
+
+	// FirstRun, if non-nil, is called by Run before the requested command
+	// runs on the first invocation of the program by the user. It may be
+	// used for onboarding tasks, such as generating a default configuration.
+	// Run records that the program has been run by creating a file in
+	// r.StateDir() after FirstRun returns; if that directory can't be used,
+	// FirstRun isn't called.
+	FirstRun func()
+
+	// VersionCommand enables a "version" command (unless the runner has a
+	// command by that name) that prints the program's version along with
+	// details of its build, as recorded by the Go toolchain: the module
+	// version, the VCS revision and whether the working tree was modified,
+	// and the Go version. With the -json flag, the command prints these as
+	// a JSON object. The version printed is r.Version or, if that is empty,
+	// the version of the program's module. VersionCommand also enables a
+	// global -version (or --version) flag, unless the program has defined
+	// one, which prints the same details as the command.
+	VersionCommand bool
+
+	// Isolate makes Run execute each command in a child process: after
+	// checking the command line, Run re-executes the program's binary with
+	// its original arguments (os.Args) and with the environment variable
+	// SUBCMD_ISOLATED set, which tells the Run call in the child process
+	// to run the command itself. The parent process enforces the timeout
+	// set by TimeoutEnv by killing the child, and it reports a child that
+	// fails or crashes in the same way regardless of the cause: it exits
+	// with the child's exit status if the error-handling behavior is
+	// ExitOnError, and otherwise Run returns an error describing how the
+	// child exited.
+	Isolate bool
+
+	// HelpWords are the arguments that request help when given in place
+	// of a command name, as in "prog help" or "prog help foo". New sets
+	// HelpWords to "help", "-h", "-help", and "--help". A program may
+	// remove some of them to use those names for commands of its own; the
+	// words that begin with "-" are also recognized as global flags.
+	HelpWords []string
+
+	// SlashHelp makes Run treat "/?" and "/help", the conventional ways
+	// of asking for help on Windows, as help words in addition to
+	// HelpWords. As the only argument of a command, they request help for
+	// that command, as in "prog foo /?". New sets SlashHelp on Windows.
+	SlashHelp bool
+
+	// Before and After, if non-nil, are called by Run immediately before
+	// and after each command runs, with the name of the command and its
+	// arguments (following any flags of the command). They may be used
+	// for setup and teardown shared by all the commands, such as opening
+	// a database or flushing logs. After is called even if the command
+	// returns an error or panics (in which case the panic continues after
+	// After returns), but not if the command exits the program.
+	Before func(name string, args []string)
+	After  func(name string, args []string)
+
+	// Middleware wraps the execution of every command, for concerns
+	// such as timing, logging, or authorization that are shared by all
+	// the commands. The first middleware is the outermost: it receives
+	// a CommandFunc that calls the second, and so on; the last receives
+	// a CommandFunc that runs the command itself. Before and After are
+	// called outside all of the middleware.
+	Middleware []func(next CommandFunc) CommandFunc
+
+	// Plugins makes Run look on PATH for commands that the program
+	// doesn't define, in the manner of git and kubectl: an executable
+	// called prog-foo provides the command foo for a program called prog.
+	// Run executes the plugin with the command's arguments and the
+	// runner's streams; if it fails under ExitOnError, the program exits
+	// with the plugin's exit status. The plugins are listed in the usage
+	// message as commands provided by plugins.
+	Plugins bool
+
+	// Exit is called to end the program with an exit status when the
+	// error-handling behavior is ExitOnError. New sets Exit to os.Exit.
+	// Exit must not return; it may be replaced to run a program's
+	// commands within a test (see the subcmdtest package).
+	Exit func(code int)
+
+	// Translate, if non-nil, translates the English messages printed by
+	// the runner and by a TableFormatter, for programs used in other
+	// languages. It is called with the text of a message or, for a message
+	// with variable parts, its fmt format string (such as "no such
+	// command %s" or "Possible commands are"), and it returns the text or
+	// format string to use instead, which must have the same verbs.
+	// Translate should return any string it doesn't recognize unchanged.
+	Translate func(s string) string
+
+	// IgnoreCase makes Run match command names, aliases, and HelpWords
+	// without regard to case, so that "prog Install" runs the command
+	// "install". Run panics if two commands at the same level have names
+	// or aliases that differ only in case.
+	IgnoreCase bool
+
+	// RecoverPanics makes Run recover from a panic by a command and report
+	// it as an error returned by the command (such as "prog foo: panic:
+	// index out of range"), rather than crashing with a stack trace that
+	// means little to the program's users. The stack trace is printed
+	// too if the GOTRACEBACK environment variable is set to a value other
+	// than "none", so that developers can still debug the panic.
+	RecoverPanics bool
+
+	// HandleSignals makes Run handle SIGINT (as from Ctrl-C) and SIGTERM
+	// while a command with a DoContext function runs: the first signal
+	// cancels the context passed to the command, and a second signal exits
+	// the program at once. If the command returns an error after the first
+	// signal, Run reports that it was interrupted and, under ExitOnError,
+	// exits with the conventional status for the signal (such as 130 for
+	// SIGINT). Other commands are unaffected by HandleSignals.
+	HandleSignals bool
+
+	// Order is the order in which the runner's commands are listed in its
+	// usage message, completions, and generated documentation. By default
+	// (DeclarationOrder), they are listed in the order in which they were
+	// given to New.
+	Order CommandOrder
+
+	// PageHelp makes Run show help that is too long to fit in the
+	// terminal through a pager: the command in the PAGER environment
+	// variable or, if that is unset, "less -FRX". Help that isn't written
+	// to a terminal is never paged.
+	PageHelp bool
+
+	// CompletionCommand enables a hidden "completion" command (unless the
+	// runner has a command by that name) that prints a completion script
+	// for the shell given as its argument (bash, zsh, fish, or powershell),
+	// as by GenBashCompletion and the like, so that users can install
+	// completions with a command such as "prog completion zsh > _prog".
+	CompletionCommand bool
+
+	// Picker makes Run, when it is used interactively (with its standard
+	// input and standard error both terminals), let the user choose a
+	// command from a numbered list instead of failing if no command is
+	// given (and there is no Default) or if the name given is not a
+	// command but resembles one or more commands. The user may cancel by
+	// entering nothing, in which case Run fails as usual.
+	Picker bool
+
+	// RecordHistory makes Run append each command that it runs, along
+	// with the time and the command's exit status, to a history file in
+	// the program's StateDir, which the program may read back by calling
+	// History (for instance, to offer the user's recent commands). Commands
+	// that aren't run, such as because of an unknown flag, are not
+	// recorded.
+	RecordHistory bool
+
+	// OnDispatch, if non-nil, is called by Run after each command runs,
+	// with the name of the command, its arguments (as for After), how long
+	// it took, and the error that Run handles because of it, or nil if it
+	// succeeded. It lets a program record metrics or usage statistics for
+	// all its commands in one place.
+	OnDispatch func(name string, args []string, dur time.Duration, err error)
+
+	// Logger, if non-nil, receives structured records of the commands that
+	// Run runs: a record at level Debug as each command starts, and one
+	// when it finishes with its duration, at level Info or, if the command
+	// failed, at level Error with the error. The records have the
+	// attributes "command" (the command's full name, such as "prog remote
+	// add"), "args", "duration", and "error".
+	Logger *slog.Logger
+
+	// DryRun asks the commands to show what they would do (such as the
+	// files they would delete) rather than doing it. It may be set by the
+	// global flag that DryRunFlag defines or by the environment variable
+	// named by DryRunEnv. Commands that honor it check r.DryRun or, in a
+	// DoContext function, IsDryRun(ctx).
+	DryRun bool
+
+	// DryRunEnv, if non-empty, names an environment variable that sets
+	// DryRun when Run is called if its value is true (as by
+	// strconv.ParseBool), so that a dry run can be requested for all the
+	// program's invocations by a script.
+	DryRunEnv string
+
+	// Verbosity is how much the commands should report about what they
+	// are doing. It is Normal unless it is set by the program or by the
+	// global flags that VerbosityFlags defines.
+	Verbosity Verbosity
+
+	// ChainSeparator, if non-empty, lets a single command line run several
+	// commands in turn: the arguments following the global flags are split
+	// wherever an argument equals ChainSeparator, and each part is run as a
+	// command line of its own. With ChainSeparator set to ";", for
+	// instance, the shell command
+	//
+	//	prog -v build \; test ./... \; deploy
+	//
+	// runs build, then test, then deploy, all with the global flag -v.
+	// By default, Run stops at the first command that fails.
+	ChainSeparator string
+
+	// ChainKeepGoing makes Run carry on with the rest of a chain of commands
+	// (see ChainSeparator) after one of them fails. Run then reports the
+	// first failure at the end: under ExitOnError, the error of each failed
+	// command is printed as usual, and Run exits with the status of the first
+	// once the chain is done; under ContinueOnError, Run returns the first
+	// error. (Under PanicOnError, Run panics at the first failure
+	// regardless.)
+	ChainKeepGoing bool
 }
 
 // New creates a Runner with the given name and command list. The error-handling
 // behavior of Run is controlled by errorHandling and has the same semantics as
 // for flag.FlagSet.
 //
-// New panics if any command is named "help", "-h", "-help", or "--help",
-// or if any two commands have the same name.
+// New panics if any command is named "__complete", or if any two commands
+// have the same name or alias. The same applies to the Subcommands of each
+// command.
 func New(name string, cmds []Command, errorHandling flag.ErrorHandling) *Runner {
-	names := make(map[string]struct{})
+	checkCommands(cmds)
+	return newRunner(name, cmds, errorHandling)
+}
+
+// newRunner is New without checking cmds, which have already been checked
+// (as the Subcommands of a command are checked along with the command).
+func newRunner(name string, cmds []Command, errorHandling flag.ErrorHandling) *Runner {
+	r := &Runner{
+		name:              name,
+		cmds:              cmds,
+		index:             indexCommands(cmds),
+		errorHandling:     errorHandling,
+		Flags:             flag.NewFlagSet(name, flag.ContinueOnError),
+		ErrorPrefix:       name,
+		NoCommandExitCode: 2,
+		HelpWords:         []string{"help", "-h", "-help", "--help"},
+		Exit:              os.Exit,
+		SlashHelp:         runtime.GOOS == "windows",
+	}
+	r.Usage = r.defaultUsage
+	return r
+}
+
+// Add adds cmd to the runner's commands, as for a plugin or a feature that
+// is only sometimes enabled. Add panics if cmd is invalid in the same ways
+// that New does, such as if the runner already has a command with the same
+// name.
+func (r *Runner) Add(cmd Command) {
+	cmds := make([]Command, len(r.cmds), len(r.cmds)+1)
+	copy(cmds, r.cmds)
+	cmds = append(cmds, cmd)
+	checkCommands(cmds)
+	r.cmds = cmds
+	r.index = indexCommands(cmds)
+	r.usage = renderedUsage{}
+}
+
+// Remove removes the command called name from the runner's commands. It
+// reports whether there was such a command.
+func (r *Runner) Remove(name string) bool {
+	for i, cmd := range r.cmds {
+		if cmd.Name == name {
+			cmds := make([]Command, 0, len(r.cmds)-1)
+			cmds = append(cmds, r.cmds[:i]...)
+			r.cmds = append(cmds, r.cmds[i+1:]...)
+			r.index = indexCommands(r.cmds)
+			r.usage = renderedUsage{}
+			return true
+		}
+	}
+	return false
+}
+
+// Commands returns a copy of the runner's commands, including hidden ones
+// but not built-in commands such as the one enabled by VersionCommand.
+func (r *Runner) Commands() []Command {
+	return append([]Command(nil), r.cmds...)
+}
+
+// Lookup returns the command that Run would run for the command name name,
+// which may be an alias or the name of a built-in command.
+func (r *Runner) Lookup(name string) (Command, bool) {
+	cmd, ok := r.lookup(name)
+	if !ok {
+		return Command{}, false
+	}
+	return *cmd, true
+}
+
+// isDefaultUsage reports whether r.Usage is the default Usage set by New
+// (for r or for another Runner).
+func (r *Runner) isDefaultUsage() bool {
+	return r.Usage != nil && reflect.ValueOf(r.Usage).Pointer() == reflect.ValueOf(r.defaultUsage).Pointer()
+}
+
+func (r *Runner) defaultUsage() {
+	w := r.helpOut
+	if w == nil {
+		w = r.Stderr()
+	}
+	f := r.formatter(w)
+	key, cacheable := r.usageKey(f)
+	if cacheable && r.usage.text != "" && r.usage.key == key {
+		io.WriteString(w, r.usage.text)
+		return
+	}
+	var b bytes.Buffer
+	cmds := r.visibleCommands()
+	f.FormatList(&b, r.name, cmds)
+	if hasFlags(r.Flags) {
+		fmt.Fprintf(&b, "\n%s\n\n", style(r.Color.enabled(w), sgrDim, r.tr("Global flags")+":"))
+		writeFlags(&b, r.Flags, nil)
+	}
+	if cacheable && !hasDescriptionFunc(cmds) {
+		r.usage = renderedUsage{key, b.String()}
+	}
+	w.Write(b.Bytes())
+}
+
+// A renderedUsage is the usage message written by a Runner's default Usage,
+// which is kept for reuse: the commands of a large program take a while to
+// list (and listing plugins means searching the PATH). The message is
+// discarded when the runner's commands change, and it isn't reused once a
+// setting that affects it changes.
+type renderedUsage struct {
+	key  usageKey
+	text string
+}
+
+// A usageKey identifies the circumstances in which a usage message was
+// rendered, which must match for the message to be reused. It covers each
+// of the runner's settings that affect the message.
+type usageKey struct {
+	argsUsage bool // the TableFormatter's ArgsUsage
+	aliases   bool // the TableFormatter's Aliases
+	color     bool
+	width     int
+	order     CommandOrder
+	version   string
+	builtins  [5]bool // Debug, Deprecations, VersionCommand, CompletionCommand, and Plugins
+	flags     *flag.FlagSet
+	numFlags  int
+}
+
+// usageKey returns the key for a usage message written with f, and whether
+// the message may be reused at all: it is only kept for a TableFormatter
+// without translation (whose function can't be compared).
+func (r *Runner) usageKey(f Formatter) (usageKey, bool) {
+	tf, ok := f.(TableFormatter)
+	if !ok || tf.Translate != nil {
+		return usageKey{}, false
+	}
+	key := usageKey{
+		argsUsage: tf.ArgsUsage,
+		aliases:   tf.Aliases,
+		color:     tf.Color,
+		width:     tf.Width,
+		order:     r.Order,
+		version:   r.Version,
+		builtins:  [5]bool{r.Debug, r.Deprecations, r.VersionCommand, r.CompletionCommand, r.Plugins},
+		flags:     r.Flags,
+	}
+	if r.Flags != nil {
+		r.Flags.VisitAll(func(*flag.Flag) { key.numFlags++ })
+	}
+	return key, true
+}
+
+// hasDescriptionFunc reports whether any of cmds has a DescriptionFunc,
+// whose description may change from one usage message to the next.
+func hasDescriptionFunc(cmds []Command) bool {
 	for _, cmd := range cmds {
-		if _, ok := helpWords[cmd.Name]; ok {
-			panicf("subcmd: cannot name a command %q", cmd.Name)
+		if cmd.DescriptionFunc != nil {
+			return true
 		}
-		if _, ok := names[cmd.Name]; ok {
-			panicf("subcmd: duplicate command %q given to Run", cmd.Name)
+	}
+	return false
+}
+
+// visibleCommands returns the commands that should be shown to users.
+func (r *Runner) visibleCommands() []Command {
+	var cmds []Command
+	for _, cmd := range r.cmds {
+		if !cmd.Hidden && r.available(&cmd) {
+			cmds = append(cmds, cmd)
 		}
-		names[cmd.Name] = struct{}{}
 	}
-	return &Runner{
-		cmds:          cmds,
-		errorHandling: errorHandling,
-		Usage:         func() { defaultUsage(name, cmds) },
+	for _, cmd := range r.builtins() {
+		if _, ok := r.lookupDefined(cmd.Name); !ok && !cmd.Hidden {
+			cmds = append(cmds, cmd)
+		}
 	}
+	r.Order.sort(cmds)
+	return cmds
 }
 
-// ErrHelp is the error returned if the first argument is "help", "-h", "-help",
-// or "--help".
+// builtins returns the built-in commands enabled for r. A command defined by
+// the program takes precedence over a built-in command with the same name.
+func (r *Runner) builtins() []Command {
+	var cmds []Command
+	if r.Debug {
+		cmds = append(cmds, Command{
+			Name:        debugCommand,
+			Description: "diagnose the program's commands",
+			Hidden:      true,
+			Subcommands: r.debugCommands(),
+		})
+	}
+	if r.Deprecations {
+		cmds = append(cmds, Command{
+			Name:        deprecationsCommand,
+			Description: "list deprecated commands",
+			Do:          func([]string) { r.writeDeprecations(r.Stdout()) },
+		})
+	}
+	if r.VersionCommand {
+		cmds = append(cmds, r.versionCommand())
+	}
+	if r.CompletionCommand {
+		cmds = append(cmds, r.completionCommand())
+	}
+	if r.Plugins {
+		cmds = append(cmds, r.pluginCommands()...)
+	}
+	return cmds
+}
+
+// available reports whether cmd is offered by the runner's version of the
+// program.
+func (r *Runner) available(cmd *Command) bool {
+	if r.Version == "" || cmd.MinVersion == "" {
+		return true
+	}
+	return compareVersions(r.Version, cmd.MinVersion) >= 0
+}
+
+// formatter returns the Formatter for help written to w.
+func (r *Runner) formatter(w io.Writer) Formatter {
+	f := r.Formatter
+	if f == nil {
+		f = TableFormatter{}
+	}
+	if tf, ok := f.(TableFormatter); ok {
+		if r.Color.enabled(w) {
+			tf.Color = true
+		}
+		if r.Translate != nil {
+			tf.Translate = r.Translate
+		}
+		if tf.Width == 0 {
+			tf.Width = terminalWidth(w)
+		}
+		f = tf
+	}
+	return f
+}
+
+// tr translates the message s using r.Translate.
+func (r *Runner) tr(s string) string {
+	if r.Translate == nil {
+		return s
+	}
+	return r.Translate(s)
+}
+
+// A translatedError is an error whose message has been translated.
+type translatedError struct {
+	err error
+	msg string
+}
+
+func (e translatedError) Error() string { return e.msg }
+func (e translatedError) Unwrap() error { return e.err }
+
+// ErrHelp is the error returned if the first argument is one of the Runner's
+// HelpWords (by default "help", "-h", "-help", or "--help").
 var ErrHelp = errors.New("subcmd: help requested")
 
+// ErrNoCommand is wrapped by the error returned if no arguments are given.
+var ErrNoCommand = errors.New("no sub-command provided")
+
+// An ExitCoder is an error that carries the exit status with which the
+// program should exit. When the error-handling behavior is ExitOnError and a
+// command's DoErr or DoContext function returns an error that is or wraps an
+// ExitCoder, Run exits with its ExitCode (after printing the error, unless
+// the code is 0) rather than with status 2.
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// WithExitCode returns an ExitCoder wrapping err whose ExitCode is code.
+func WithExitCode(err error, code int) error {
+	return exitCodeError{err, code}
+}
+
+type exitCodeError struct {
+	err  error
+	code int
+}
+
+func (e exitCodeError) Error() string { return e.err.Error() }
+func (e exitCodeError) Unwrap() error { return e.err }
+func (e exitCodeError) ExitCode() int { return e.code }
+
 // Run parses args and dispatches to the correct subcommand.
 // It produces an error message listing the commands with their descriptions if
-// a nonexistent subcommand is provided, or if the command is "help", "-h",
-// "-help", or "--help". This error message may be customized by altering
-// r.Usage.
+// a nonexistent subcommand is provided, or if the command is one of
+// r.HelpWords (by default "help", "-h", "-help", or "--help"). This error
+// message may be customized by altering r.Usage. If "help" or a help flag
+// precedes a command name, as in
+// "prog help foo", "prog --help foo", or (if there are global flags)
+// "prog -v -h foo", the help message is for that command instead.
+//
+// An argument "--" ends the global flags, and the argument following it is
+// taken literally as a command name: "prog -- help" runs a command called
+// "help" (or, if there is none, reports that there is no such command)
+// rather than printing help. Similarly, "--" following the command name ends
+// the command's Flags, and the arguments after it are passed to the command
+// even if they look like flags. (For a command without Flags, the "--" is
+// passed on to the command as well.)
+//
+// If the command's DoErr or DoContext function returns an error, Run wraps
+// it with the command's full name (such as "prog remote add") and handles it
+// according to the error-handling behavior: with ExitOnError, for instance,
+// Run prints the error and exits with status 2, but doesn't print the usage
+// message.
+//
+// If the command is "__complete", Run prints the completions for the
+// remaining arguments (as computed by r.Complete) to stdout, one per line.
+// Shell completion scripts use this to complete the program's arguments.
+//
+// Run panics if any command, or any of the Subcommands of a command, is
+// named by one of r.HelpWords.
 func (r *Runner) Run(args []string) error {
+	return r.RunContext(context.Background(), args)
+}
+
+// RunContext is like Run, but passes ctx to the DoContext function of the
+// command that it runs. If the environment variable named by r.TimeoutEnv
+// sets a timeout, the context given to DoContext has the corresponding
+// deadline (rather than Run abandoning the command when the time is up),
+// and Run treats an error returned after the deadline has passed as a
+// timeout.
+func (r *Runner) RunContext(ctx context.Context, args []string) error {
+	r.checkHelpWords(r.cmds)
+	if r.IgnoreCase {
+		checkFoldedNames(r.cmds)
+	}
+	if r.dryRunFromEnv() {
+		r.DryRun = true
+	}
+	orig := args
+	if r.argv == nil {
+		// A runner for Subcommands inherits the full command line.
+		r.argv = orig
+		defer func() { r.argv = nil }()
+	}
+	args, opts, err := r.parseFlags(args)
+	if err != nil {
+		return r.errorExit(args, err)
+	}
+	if r.DryRun {
+		ctx = context.WithValue(ctx, dryRunKey{}, true)
+	}
+	if opts.help {
+		return r.help(args)
+	}
+	if opts.version {
+		r.printVersion(false)
+		return nil
+	}
+	if r.ChainSeparator != "" {
+		if chain := r.splitChain(args); len(chain) > 1 {
+			return r.runChain(ctx, orig[:len(orig)-len(args)], chain, opts)
+		}
+	}
+	return r.dispatch(ctx, args, opts)
+}
+
+// dispatch runs the command named by args[0] (or the default command, if
+// args is empty) with the rest of args, given the options set by the
+// global flags.
+func (r *Runner) dispatch(ctx context.Context, args []string, opts runOptions) error {
+	if len(args) < 1 {
+		if name := r.defaultCommand(); name != "" {
+			args = []string{name}
+		} else if name, ok := r.pick(r.tr("Choose a command:"), r.visibleCommands()); ok {
+			args = []string{name}
+		}
+	}
 	if len(args) < 1 {
-		return r.errorExit(args, errors.New("subcmd: no sub-command provided"))
+		if r.NoCommand != nil {
+			r.NoCommand()
+			return nil
+		}
+		return r.errorExit(args, r.errorf("%w", translatedError{ErrNoCommand, r.tr(ErrNoCommand.Error())}))
+	}
+	if r.isHelpWord(args[0]) && !opts.literal {
+		return r.help(args[1:])
+	}
+	if args[0] == completeCommand && !opts.literal {
+		r.printCompletions(args[1:])
+		return nil
+	}
+	if cmd, ok := r.lookup(args[0]); ok {
+		eh := r.errorHandlingFor(cmd)
+		if !r.available(cmd) {
+			err := r.errorf("command %s requires version %s or later (this is version %s)",
+				quoteArg(cmd.Name), cmd.MinVersion, r.Version)
+			return r.handleError(eh, args, err)
+		}
+		if cmd.Stability == Alpha && r.AlphaFlag != "" && !opts.alpha {
+			err := r.errorf("%s is an alpha command; run '%s %s %s' to use it anyway",
+				quoteArg(cmd.Name), r.name, r.AlphaFlag, cmd.Name)
+			return r.handleError(eh, args, err)
+		}
+		r.warnDeprecated(cmd)
+		if len(cmd.Subcommands) > 0 {
+			return r.child(cmd).RunContext(ctx, args[1:])
+		}
+		if len(args) == 2 && r.isSlashHelp(args[1]) {
+			return r.help(args[:1])
+		}
+		cmdArgs, err := r.parseCommandFlags(cmd, args[1:])
+		if err == ErrHelp {
+			return r.help(args[:1])
+		}
+		if err != nil {
+			return r.handleError(eh, args, err)
+		}
+		if err := r.checkArgs(cmd, cmdArgs); err != nil {
+			return r.handleError(eh, args, err)
+		}
+		r.firstRun()
+		if r.Isolate && os.Getenv(isolatedEnv) == "" {
+			return r.runIsolated(cmd, r.argv)
+		}
+		sigCtx, stopSignals := ctx, func() os.Signal { return nil }
+		if r.HandleSignals && cmd.DoContext != nil {
+			sigCtx, stopSignals = r.handleSignals(ctx)
+		}
+		cmdCtx, stop, err := r.startTimeout(sigCtx, cmd)
+		if err != nil {
+			stopSignals()
+			return r.handleError(eh, args, err)
+		}
+		defer stop()
+		if r.Logger != nil {
+			r.logStart(ctx, cmd, cmdArgs)
+		}
+		start := time.Now()
+		err = r.runTimed(r.withStreams(cmdCtx), cmd, cmdArgs)
+		sig := stopSignals()
+		switch {
+		case err == nil:
+		case cmdCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+			d, fromEnv, _ := r.commandTimeout(cmd)
+			err = r.timeoutError(d, fromEnv)
+		case sig != nil:
+			err = r.interruptedError(cmd, sig)
+		default:
+			err = r.wrapCommandError(cmd, err)
+		}
+		dur := time.Since(start)
+		if r.OnDispatch != nil {
+			r.OnDispatch(cmd.Name, cmdArgs, dur, err)
+		}
+		if r.Logger != nil {
+			r.logDone(ctx, cmd, cmdArgs, dur, err)
+		}
+		if r.RecordHistory {
+			r.recordHistory(start, args, err)
+		}
+		if err != nil {
+			return r.handleError(eh, args, err)
+		}
+		return nil
+	}
+	if name, ok := r.pick(fmt.Sprintf(r.tr("There is no command %s. Choose one of these:"), quoteArg(args[0])),
+		r.similarCommands(args[0])); ok {
+		return r.dispatch(ctx, append([]string{name}, args[1:]...), opts)
+	}
+	if r.CommandNotFound != nil {
+		r.CommandNotFound(args[0])
 	}
-	if _, ok := helpWords[args[0]]; ok {
+	return r.errorExit(args, r.notFound(args[0]))
+}
+
+// defaultCommand returns the name of the command to run when none is given,
+// or the empty string if there is none.
+func (r *Runner) defaultCommand() string {
+	if r.DefaultEnv != "" {
+		if name := os.Getenv(r.DefaultEnv); name != "" {
+			return name
+		}
+	}
+	return r.Default
+}
+
+// runCommand runs cmd with args through r.Middleware, surrounded by calls
+// to r.Before and r.After.
+func (r *Runner) runCommand(ctx context.Context, cmd *Command, args []string) error {
+	if r.Before != nil {
+		r.Before(cmd.Name, args)
+	}
+	if r.After != nil {
+		defer r.After(cmd.Name, args)
+	}
+	run := func(ctx context.Context, _ string, args []string) (err error) {
+		if r.RecoverPanics {
+			defer r.recoverPanic(&err)
+		}
+		return cmd.run(ctx, args)
+	}
+	for i := len(r.Middleware) - 1; i >= 0; i-- {
+		run = r.Middleware[i](run)
+	}
+	return run(ctx, cmd.Name, args)
+}
+
+// runOptions are the options set by built-in global flags.
+type runOptions struct {
+	alpha   bool // r.AlphaFlag was given
+	help    bool // -h or -help was given
+	version bool // -version was given
+	literal bool // "--" preceded the command name
+}
+
+// parseFlags parses the global flags at the beginning of args and returns
+// the remaining arguments.
+func (r *Runner) parseFlags(args []string) (rest []string, opts runOptions, err error) {
+	alphaName := strings.TrimLeft(r.AlphaFlag, "-")
+	if alphaName == r.AlphaFlag || alphaName == "" {
+		// AlphaFlag isn't a flag, but a word like "alpha".
+		if r.AlphaFlag != "" && len(args) > 0 && args[0] == r.AlphaFlag {
+			opts.alpha = true
+			args = args[1:]
+		}
+		alphaName = ""
+	}
+	if !hasFlags(r.Flags) && alphaName == "" && !r.VersionCommand {
+		if len(args) > 0 && args[0] == "--" {
+			opts.literal = true
+			args = args[1:]
+		}
+		return args, opts, nil
+	}
+	fs := flag.NewFlagSet(r.name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Usage = func() {}
+	if r.Flags != nil {
+		r.Flags.VisitAll(func(f *flag.Flag) {
+			fs.Var(f.Value, f.Name, f.Usage)
+		})
+	}
+	if alphaName != "" {
+		fs.BoolVar(&opts.alpha, alphaName, false, "")
+	}
+	// Define the help flags (unless the program has taken those names)
+	// so that help requests for a command, as in "prog -v --help foo",
+	// are recognized after other global flags.
+	for _, word := range r.HelpWords {
+		name := strings.TrimLeft(word, "-")
+		if name != word && name != "" && fs.Lookup(name) == nil {
+			fs.BoolVar(&opts.help, name, false, "")
+		}
+	}
+	if r.VersionCommand && fs.Lookup(versionCommand) == nil {
+		fs.BoolVar(&opts.version, versionCommand, false, "")
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return args, opts, ErrHelp
+		}
+		// The flag package's messages include the offending argument.
+		return args, opts, r.errorf("%s", sanitize(err.Error()))
+	}
+	rest = fs.Args()
+	if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+		opts.literal = true
+	}
+	return rest, opts, nil
+}
+
+// help reports a request for help. If args begins with the name of a
+// command, as in "prog help foo", the help is for that command.
+func (r *Runner) help(args []string) error {
+	if len(args) == 0 {
 		return r.errorExit(args, ErrHelp)
 	}
-	for _, cmd := range r.cmds {
-		if cmd.Name == args[0] {
-			cmd.Do(args[1:])
-			return nil
+	cmd, ok := r.lookup(args[0])
+	if !ok {
+		return r.errorExit(nil, r.notFound(args[0]))
+	}
+	if !r.available(cmd) {
+		return r.errorExit(args, ErrHelp)
+	}
+	if len(cmd.Subcommands) > 0 {
+		return r.child(cmd).help(args[1:])
+	}
+	if r.errorHandling == flag.ExitOnError {
+		r.pageHelp(func(w io.Writer) {
+			r.formatter(w).FormatCommand(w, r.name+" "+cmd.Name, *cmd)
+		})
+		r.Exit(r.HelpExitCode)
+	}
+	return r.errorExit(args, ErrHelp)
+}
+
+// lookup returns the command called name, which may be a built-in command.
+func (r *Runner) lookup(name string) (*Command, bool) {
+	if cmd, ok := r.lookupDefined(name); ok {
+		return cmd, true
+	}
+	for _, cmd := range r.builtins() {
+		if r.sameName(cmd.Name, name) {
+			return &cmd, true
+		}
+	}
+	return nil, false
+}
+
+// lookupDefined returns the command called name defined by the program.
+// The name may be one of the command's aliases.
+func (r *Runner) lookupDefined(name string) (*Command, bool) {
+	i, ok := r.index.exact[name]
+	if !ok && r.IgnoreCase {
+		i, ok = r.index.folded[strings.ToLower(name)]
+	}
+	if !ok {
+		return nil, false
+	}
+	return &r.cmds[i], true
+}
+
+// A commandIndex maps the names and aliases of a runner's commands to their
+// positions in its list of commands, so that looking up a command doesn't
+// take longer for programs with many commands.
+type commandIndex struct {
+	exact  map[string]int
+	folded map[string]int // keyed by lower-case names, for IgnoreCase
+}
+
+func indexCommands(cmds []Command) commandIndex {
+	index := commandIndex{
+		exact:  make(map[string]int, len(cmds)),
+		folded: make(map[string]int, len(cmds)),
+	}
+	add := func(name string, i int) {
+		if _, ok := index.exact[name]; !ok {
+			index.exact[name] = i
+		}
+		if _, ok := index.folded[strings.ToLower(name)]; !ok {
+			index.folded[strings.ToLower(name)] = i
+		}
+	}
+	// Names take precedence over aliases.
+	for i, cmd := range cmds {
+		add(cmd.Name, i)
+	}
+	for i, cmd := range cmds {
+		for _, alias := range cmd.Aliases {
+			add(alias, i)
+		}
+	}
+	return index
+}
+
+// sameName reports whether the command names a and b match, ignoring case
+// if r.IgnoreCase is set.
+func (r *Runner) sameName(a, b string) bool {
+	if r.IgnoreCase {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// checkFoldedNames panics if any two of cmds, or of the subcommands of any
+// one of them, have names or aliases that differ only in case.
+func checkFoldedNames(cmds []Command) {
+	names := make(map[string]string)
+	for _, cmd := range cmds {
+		for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+			folded := strings.ToLower(name)
+			if prev, ok := names[folded]; ok && prev != name {
+				panicf("subcmd: command names %q and %q differ only in case", prev, name)
+			}
+			names[folded] = name
+		}
+		checkFoldedNames(cmd.Subcommands)
+	}
+}
+
+// errorf is like fmt.Errorf, but the error message begins with r.ErrorPrefix.
+func (r *Runner) errorf(format string, args ...interface{}) error {
+	format = r.tr(format)
+	if r.ErrorPrefix != "" {
+		format = "%s: " + format
+		args = append([]interface{}{r.ErrorPrefix}, args...)
+	}
+	return fmt.Errorf(format, args...)
+}
+
+// printError prints err, which will cause the program to exit with the given
+// status, to r's standard error stream. The path is the full name of the
+// command that failed (such as "prog foo"), or else the runner's name.
+func (r *Runner) printError(err error, code int, path string) {
+	w := r.Stderr()
+	if r.ErrorFormat == JSONErrors {
+		json.NewEncoder(w).Encode(jsonError{
+			Code:    code,
+			Command: path,
+			Message: err.Error(),
+		})
+		return
+	}
+	fmt.Fprintln(w, style(r.Color.enabled(w), sgrBold+sgrRed, err.Error()))
+}
+
+// commandPath returns the full name of the command given by args, if there is
+// one, or else the runner's name.
+func (r *Runner) commandPath(args []string) string {
+	if len(args) > 0 {
+		if cmd, ok := r.lookup(args[0]); ok {
+			return r.name + " " + cmd.Name
 		}
 	}
-	err := fmt.Errorf("subcmd: no such command %q", args[0])
-	return r.errorExit(args, err)
+	return r.name
+}
+
+// wrapCommandError wraps err, which cmd failed with, in an error whose message
+// begins with the full name of the command (such as "prog remote add"). The
+// name follows r.ErrorPrefix unless the prefix is just the runner's name.
+func (r *Runner) wrapCommandError(cmd *Command, err error) error {
+	path := r.name + " " + cmd.Name
+	if r.ErrorPrefix == "" || r.ErrorPrefix == r.name {
+		return fmt.Errorf(r.tr("%s: %w"), path, commandError{err})
+	}
+	return r.errorf("%s: %w", path, commandError{err})
 }
 
 func (r *Runner) errorExit(args []string, err error) error {
-	switch r.errorHandling {
+	return r.handleError(r.errorHandling, args, err)
+}
+
+// errorHandlingFor returns the error-handling behavior for the failures of
+// cmd, which may override r's.
+func (r *Runner) errorHandlingFor(cmd *Command) flag.ErrorHandling {
+	if cmd.ErrorHandling != nil {
+		return *cmd.ErrorHandling
+	}
+	return r.errorHandling
+}
+
+// exitCode returns the status with which the program exits because of err
+// under ExitOnError.
+func (r *Runner) exitCode(err error) int {
+	code := 2
+	switch {
+	case err == nil:
+		return 0
+	case err == ErrHelp:
+		code = r.HelpExitCode
+	case errors.Is(err, ErrNoCommand):
+		code = r.NoCommandExitCode
+	}
+	var ec ExitCoder
+	if errors.As(err, &ec) {
+		code = ec.ExitCode()
+	}
+	return code
+}
+
+// handleError handles err, which occurred while running the command line
+// args, according to the error-handling behavior eh.
+func (r *Runner) handleError(eh flag.ErrorHandling, args []string, err error) error {
+	switch eh {
 	case flag.ContinueOnError:
 		return err
 	case flag.PanicOnError:
 		panic(err)
 	case flag.ExitOnError:
-		r.Usage()
-		if err == ErrHelp {
-			os.Exit(0)
+		code := r.exitCode(err)
+		if err != ErrHelp && code != 0 {
+			r.printError(err, code, r.commandPath(args))
 		}
-		os.Exit(2)
+		if r.chain.fail(code) {
+			// The rest of the chain still runs, and Run exits later.
+			return err
+		}
+		// Tools that want JSON errors don't want usage messages, and a
+		// command that fails or times out wasn't used incorrectly.
+		var cmdErr commandError
+		if (r.ErrorFormat != JSONErrors || err == ErrHelp) &&
+			!errors.As(err, &cmdErr) && !errors.Is(err, errTimedOut) {
+			var usageErr commandUsageError
+			if errors.As(err, &usageErr) {
+				// Show the usage of the command that was misused.
+				cmd := usageErr.cmd
+				w := r.Stderr()
+				r.formatter(w).FormatCommand(w, r.name+" "+cmd.Name, *cmd)
+			} else {
+				if err == ErrHelp || code == 0 {
+					r.pageHelp(func(w io.Writer) {
+						r.helpOut = w
+						defer func() { r.helpOut = nil }()
+						r.Usage()
+					})
+				} else {
+					r.Usage()
+				}
+			}
+		}
+		r.Exit(code)
 	default:
-		panicf("subcmd: bad ErrorHandling value %d", r.errorHandling)
+		panicf("subcmd: bad ErrorHandling value %d", eh)
 	}
 	panic("unreached")
 }
@@ -103,43 +1394,153 @@ func (r *Runner) errorExit(args []string, err error) error {
 // If the command provided isn't one of those in cmds, Run calls os.Exit(2)
 // after printing the error message.
 //
-// Run panics if any command is named "help", "-h", "-help", or "--help",
-// or if any two commands have the same name.
+// Run panics if any command is named "help", "-h", "-help", "--help", or
+// "__complete", or if any two commands have the same name.
 func Run(cmds []Command) {
 	r := New(os.Args[0], cmds, flag.ExitOnError)
-	r.Usage = func() { Usage(cmds) }
+	r.Usage = func() {
+		usageOut = r.helpOut
+		defer func() { usageOut = nil }()
+		Usage(cmds)
+	}
 	r.Run(os.Args[1:])
 }
 
+// RunE is like Run, but it returns an error rather than printing it and
+// exiting, so that main can decide how the program exits and tests can call
+// it safely. The error is ErrHelp if help was requested, in which case the
+// caller may print the usage message by calling Usage.
+func RunE(cmds []Command) error {
+	return New(os.Args[0], cmds, flag.ContinueOnError).Run(os.Args[1:])
+}
+
 func panicf(format string, args ...interface{}) {
 	panic(fmt.Sprintf(format, args...))
 }
 
-var helpWords = map[string]struct{}{
-	"help":   {},
-	"-h":     {},
-	"-help":  {},
-	"--help": {},
+// maxEcho is the maximum number of bytes of an argument that is repeated in
+// an error message.
+const maxEcho = 64
+
+// quoteArg quotes a command-line argument for inclusion in a message.
+// Arguments may come from untrusted sources, so control characters and
+// invalid UTF-8 are escaped and long arguments are truncated.
+func quoteArg(s string) string {
+	if len(s) <= maxEcho {
+		return strconv.Quote(s)
+	}
+	return strconv.Quote(truncate(s, maxEcho)) + "..."
 }
 
-// Usage prints a help message listing the possible commands.
-// The function is a variable that may be changed to point at a custom function.
-var Usage = func(cmds []Command) {
-	defaultUsage(os.Args[0], cmds)
+// sanitize escapes the control characters and invalid UTF-8 in a message
+// that may contain command-line arguments, and truncates it if it is
+// excessively long.
+func sanitize(s string) string {
+	var b strings.Builder
+	t := truncate(s, 4*maxEcho)
+	for i, r := range t {
+		switch {
+		case r == utf8.RuneError && !strings.HasPrefix(t[i:], "\uFFFD"):
+			fmt.Fprintf(&b, `\x%02x`, t[i])
+		case unicode.IsPrint(r):
+			b.WriteRune(r)
+		default:
+			q := strconv.QuoteRune(r)
+			b.WriteString(q[1 : len(q)-1])
+		}
+	}
+	if len(t) < len(s) {
+		b.WriteString("...")
+	}
+	return b.String()
 }
 
-func defaultUsage(name string, cmds []Command) {
-	fmt.Fprintf(os.Stderr, "Usage:\n\n  %s COMMAND\n\nPossible commands are:\n\n", name)
-	PrintDefaults(cmds)
-	fmt.Fprintf(os.Stderr, "\nRun '%s COMMAND -h' to see more information about a command.\n", name)
+// truncate returns the longest prefix of s that is at most n bytes long and
+// doesn't split a UTF-8 encoded character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
 }
 
-// PrintDefaults formats a list of commands. For each command, the output is
-//   Name    Description
-func PrintDefaults(cmds []Command) {
-	tw := tabwriter.NewWriter(os.Stderr, 0, 0, 4, ' ', 0)
+func (r *Runner) isHelpWord(arg string) bool {
+	if r.isSlashHelp(arg) {
+		return true
+	}
+	for _, word := range r.HelpWords {
+		if r.sameName(word, arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSlashHelp reports whether arg is "/?" or "/help" and r.SlashHelp is
+// set.
+func (r *Runner) isSlashHelp(arg string) bool {
+	return r.SlashHelp && (arg == "/?" || strings.EqualFold(arg, "/help"))
+}
+
+// checkHelpWords panics if any of cmds, or of their subcommands, is named
+// by one of r.HelpWords.
+func (r *Runner) checkHelpWords(cmds []Command) {
+	for _, cmd := range cmds {
+		for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+			if r.isHelpWord(name) {
+				panicf("subcmd: cannot name a command %q", name)
+			}
+		}
+		r.checkHelpWords(cmd.Subcommands)
+	}
+}
+
+// Usage prints a help message listing the possible commands, except for
+// hidden ones. It writes to standard error or, when the package-level Run
+// shows help that the user asked for, to standard output.
+// The function is a variable that may be changed to point at a custom function.
+var Usage = func(cmds []Command) {
+	w := usageOut
+	if w == nil {
+		w = os.Stderr
+	}
+	var visible []Command
 	for _, cmd := range cmds {
-		fmt.Fprintf(tw, "  %s\t%s\n", cmd.Name, cmd.Description)
+		if !cmd.Hidden {
+			visible = append(visible, cmd)
+		}
 	}
-	tw.Flush()
+	f := TableFormatter{ArgsUsage: ShowArgsUsage, Width: terminalWidth(w)}
+	f.FormatList(w, os.Args[0], visible)
+}
+
+// ShowArgsUsage makes Usage, PrintDefaults, and FprintDefaults show each
+// command's ArgsUsage in a column between its name and its description, as
+// TableFormatter.ArgsUsage does for a Runner.
+var ShowArgsUsage bool
+
+// usageOut is where the default Usage writes, if not to os.Stderr.
+var usageOut io.Writer
+
+// PrintDefaults formats a list of commands to standard error. For each
+// command, the output is
+//
+//	Name    Description
+//
+// or, if ShowArgsUsage is set,
+//
+//	Name    ArgsUsage    Description
+func PrintDefaults(cmds []Command) {
+	FprintDefaults(os.Stderr, cmds)
+}
+
+// FprintDefaults is like PrintDefaults, but writes the list to w. The
+// descriptions are wrapped to the width of the terminal to which w writes
+// or, if w isn't a terminal, to the width given by $COLUMNS, if either is
+// known.
+func FprintDefaults(w io.Writer, cmds []Command) {
+	TableFormatter{ArgsUsage: ShowArgsUsage, Width: terminalWidth(w)}.writeTable(w, cmds)
 }
@@ -0,0 +1,89 @@
+// Package subcmdtest helps test programs built with subcmd. It runs a
+// program's commands in the test process and records what they print and
+// how the program would have exited, so that a CLI can be tested with
+// table-driven tests instead of by building and running its binary:
+//
+//	func TestCLI(t *testing.T) {
+//		for _, tt := range []struct {
+//			args []string
+//			want string
+//			code int
+//		}{
+//			{[]string{"greet", "gopher"}, "hello, gopher\n", 0},
+//			{[]string{"greet"}, "", 2},
+//		} {
+//			res := subcmdtest.Run(newRunner(), tt.args...)
+//			if res.Stdout != tt.want || res.ExitCode != tt.code {
+//				t.Errorf("%q: got %+v", tt.args, res)
+//			}
+//		}
+//	}
+package subcmdtest
+
+import (
+	"bytes"
+	"runtime"
+
+	"github.com/cespare/subcmd"
+)
+
+// A Result describes a run of a program's command.
+type Result struct {
+	Stdout string // what was written to the runner's Stdout
+	Stderr string // what was written to the runner's Stderr
+	// Exited reports whether the program would have exited, and ExitCode
+	// is the exit status it would have used. If the runner's error-handling
+	// behavior is ExitOnError, a run that doesn't exit has succeeded and
+	// ExitCode is 0.
+	Exited   bool
+	ExitCode int
+	// Err is the error returned by r.Run, which is always nil under
+	// ExitOnError.
+	Err error
+}
+
+// Run runs r with the arguments args (which don't include the program
+// name), as by r.Run, and returns the result.
+//
+// While r runs, its Streams.Out and Streams.Err are replaced with buffers
+// and its Exit is replaced with a function that records the exit status
+// and stops the run; these fields are restored when Run returns. Commands
+// must write their output to r.Stdout() and r.Stderr() (or, given a context,
+// to subcmd.Stdout(ctx) and subcmd.Stderr(ctx)), not to os.Stdout and
+// os.Stderr, for it to be captured. Standard input comes from r.Streams.In,
+// which may be set beforehand. If a command panics, so does Run.
+func Run(r *subcmd.Runner, args ...string) Result {
+	var stdout, stderr bytes.Buffer
+	streams, exit := r.Streams, r.Exit
+	defer func() { r.Streams, r.Exit = streams, exit }()
+	r.Streams.Out = &stdout
+	r.Streams.Err = &stderr
+
+	var res Result
+	r.Exit = func(code int) {
+		res.Exited = true
+		res.ExitCode = code
+		// End the goroutine that is running r, as exiting the
+		// program would.
+		runtime.Goexit()
+	}
+	done := make(chan interface{})
+	go func() {
+		var panicked bool
+		defer func() {
+			if panicked {
+				done <- recover()
+			}
+			close(done)
+		}()
+		panicked = true
+		res.Err = r.Run(args)
+		panicked = false
+	}()
+	if p := <-done; p != nil {
+		panic(p)
+	}
+	res.Stdout = stdout.String()
+	res.Stderr = stderr.String()
+	return res
+}
@@ -0,0 +1,164 @@
+package subcmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An UnknownCommandError is wrapped by the error that Run returns when it is
+// given the name of a command that doesn't exist. It lets a program that uses
+// ContinueOnError report the mistake in its own way:
+//
+//	var unknown *subcmd.UnknownCommandError
+//	if errors.As(err, &unknown) {
+//		...
+//	}
+//
+// A suggested command that is nested under another is given by its path, such
+// as "remote add".
+type UnknownCommandError struct {
+	Name        string   // the name that was given
+	Commands    []string // the names of the visible commands
+	Suggestions []string // the commands closest to Name, if any are close enough
+	msg         string   // the message, as translated by the Runner
+}
+
+func (e *UnknownCommandError) Error() string {
+	if e.msg != "" {
+		return e.msg
+	}
+	return fmt.Sprintf("no such command %s", quoteArg(e.Name))
+}
+
+// notFound returns the error reported when there is no command called name.
+func (r *Runner) notFound(name string) error {
+	e := &UnknownCommandError{
+		Name:        name,
+		Suggestions: r.suggestions(name),
+	}
+	for _, cmd := range r.visibleCommands() {
+		e.Commands = append(e.Commands, cmd.Name)
+	}
+	e.msg = fmt.Sprintf(r.tr("no such command %s"), quoteArg(name)) + r.didYouMean(e.Suggestions)
+	return r.errorf("%w", e)
+}
+
+// maxSuggestions is the largest number of commands suggested in place of an
+// unknown one.
+const maxSuggestions = 3
+
+// suggestions returns the names of the visible commands (including their
+// aliases) that are closest to name, which isn't a command, if any are close
+// enough to be likely to be what the user meant. The Subcommands of the
+// commands are searched as well, so that a name given at the wrong level is
+// suggested by its path, such as "remote add".
+func (r *Runner) suggestions(name string) []string {
+	if len(name) > maxEcho {
+		return nil
+	}
+	max := 1 + len(name)/4
+	if max > 3 {
+		max = 3
+	}
+	var best []string
+	var search func(prefix string, cmds []Command)
+	search = func(prefix string, cmds []Command) {
+		for _, cmd := range cmds {
+			path := prefix + cmd.Name
+			for _, candidate := range append([]string{cmd.Name}, cmd.Aliases...) {
+				d := editDistance(name, candidate)
+				if d > max {
+					continue
+				}
+				if d < max {
+					max = d
+					best = best[:0]
+				}
+				if len(best) < maxSuggestions && !contains(best, path) {
+					best = append(best, path)
+				}
+			}
+		}
+		for i := range cmds {
+			if len(cmds[i].Subcommands) > 0 {
+				search(prefix+cmds[i].Name+" ", r.child(&cmds[i]).definedCommands())
+			}
+		}
+	}
+	search("", r.visibleCommands())
+	return best
+}
+
+// definedCommands returns r's visible commands, not counting built-in ones.
+func (r *Runner) definedCommands() []Command {
+	var cmds []Command
+	for _, cmd := range r.cmds {
+		if !cmd.Hidden && r.available(&cmd) {
+			cmds = append(cmds, cmd)
+		}
+	}
+	r.Order.sort(cmds)
+	return cmds
+}
+
+// didYouMean formats suggestions as a hint to be appended to an error
+// message, such as ` (did you mean "status" or "stash"?)`.
+func (r *Runner) didYouMean(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		quoted[i] = quoteArg(s)
+	}
+	or := r.tr("or")
+	var list string
+	switch len(quoted) {
+	case 1:
+		list = quoted[0]
+	case 2:
+		list = quoted[0] + " " + or + " " + quoted[1]
+	default:
+		list = strings.Join(quoted[:len(quoted)-1], ", ") + ", " + or + " " + quoted[len(quoted)-1]
+	}
+	return fmt.Sprintf(r.tr(" (did you mean %s?)"), list)
+}
+
+// editDistance returns the number of single-character insertions,
+// deletions, substitutions, and transpositions of adjacent characters needed
+// to turn a into b.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// d[i][j] is the distance between s[:i] and t[:j].
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,39 @@
+package subcmd
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestSuggestionsNested(t *testing.T) {
+	cmds := []Command{
+		{Name: "status", Do: func([]string) {}},
+		{
+			Name: "remote",
+			Subcommands: []Command{
+				{Name: "add", Do: func([]string) {}},
+				{Name: "secret", Do: func([]string) {}, Hidden: true},
+			},
+		},
+	}
+	for _, tt := range []struct {
+		name string
+		want []string
+	}{
+		{"add", []string{"remote add"}},
+		{"statsu", []string{"status"}},
+		{"secret", nil},
+	} {
+		r := New("prog", cmds, flag.ContinueOnError)
+		err := r.Run([]string{tt.name})
+		var unknown *UnknownCommandError
+		if !errors.As(err, &unknown) {
+			t.Fatalf("Run(%q): got error %v; want an UnknownCommandError", tt.name, err)
+		}
+		if !reflect.DeepEqual(unknown.Suggestions, tt.want) {
+			t.Errorf("Run(%q): got suggestions %q; want %q", tt.name, unknown.Suggestions, tt.want)
+		}
+	}
+}
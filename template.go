@@ -0,0 +1,99 @@
+package subcmd
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// TemplateFormatter is a Formatter that renders help using text/template
+// templates, so that a program can restyle its help without implementing a
+// Formatter of its own. A nil template is replaced by the corresponding
+// method of TableFormatter.
+//
+// The templates may use the functions in TemplateFuncs, which must be added
+// to them before they are parsed:
+//
+//	t := template.Must(template.New("usage").Funcs(subcmd.TemplateFuncs).Parse(`
+//	{{.Name}} - a tool for managing widgets
+//	{{range .Groups}}
+//	{{.Heading}}:
+//	{{commandTable .Commands}}{{end}}`))
+//	r.Formatter = subcmd.TemplateFormatter{List: t}
+type TemplateFormatter struct {
+	List    *template.Template // executed with a ListData to write FormatList
+	Command *template.Template // executed with a CommandData to write FormatCommand
+}
+
+// ListData is the data for TemplateFormatter.List.
+type ListData struct {
+	Name     string         // the program's name
+	Commands []Command      // the commands, in order
+	Groups   []CommandGroup // the commands, grouped as by TableFormatter
+}
+
+// A CommandGroup is a group of commands listed under a heading, such as the
+// commands in a Category.
+type CommandGroup struct {
+	Heading  string
+	Commands []Command
+}
+
+// CommandData is the data for TemplateFormatter.Command.
+type CommandData struct {
+	Name     string  // the full name of the command (e.g., "prog foo")
+	Synopsis string  // a synopsis of the command line (e.g., "prog foo [-n count] FILE")
+	Command  Command // the command
+}
+
+// TemplateFuncs are functions for use in the templates of a
+// TemplateFormatter:
+//
+//	commandTable CMDS  the commands CMDS as an aligned table of names and
+//	                   descriptions, as in TableFormatter's listing
+//	description CMD    the description of the command CMD, with badges
+//	                   such as "[deprecated]"
+//	flagDefaults FS    the flags of the FlagSet FS, as by flag.PrintDefaults
+//	indent PREFIX S    S with PREFIX added to each non-blank line
+var TemplateFuncs = template.FuncMap{
+	"commandTable": func(cmds []Command) string {
+		var b strings.Builder
+		TableFormatter{}.writeTable(&b, cmds)
+		return b.String()
+	},
+	"description": func(cmd Command) string { return badgedDescription(&cmd) },
+	"flagDefaults": func(fs *flag.FlagSet) string {
+		if fs == nil {
+			return ""
+		}
+		var b strings.Builder
+		writeFlags(&b, fs, nil)
+		return b.String()
+	},
+	"indent": func(prefix, s string) string { return indent(s, prefix) },
+}
+
+// FormatList implements Formatter.
+func (f TemplateFormatter) FormatList(w io.Writer, name string, cmds []Command) error {
+	if f.List == nil {
+		return TableFormatter{}.FormatList(w, name, cmds)
+	}
+	data := ListData{Name: name, Commands: cmds}
+	for _, g := range groupCommands(cmds, func(s string) string { return s }) {
+		data.Groups = append(data.Groups, CommandGroup{Heading: g.heading, Commands: g.cmds})
+	}
+	return f.List.Execute(w, data)
+}
+
+// FormatCommand implements Formatter.
+func (f TemplateFormatter) FormatCommand(w io.Writer, name string, cmd Command) error {
+	if f.Command == nil {
+		return TableFormatter{}.FormatCommand(w, name, cmd)
+	}
+	return f.Command.Execute(w, CommandData{
+		Name:     name,
+		Synopsis: synopsis(name, &cmd),
+		Command:  cmd,
+	})
+}
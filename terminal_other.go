@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package subcmd
+
+import "os"
+
+// fileIsTerminal reports whether f is a character device, which is the
+// closest to a terminal that is detected on this platform.
+func fileIsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,12 @@
+package subcmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIsTerminal reports whether f is a console.
+func fileIsTerminal(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
@@ -0,0 +1,69 @@
+package subcmd
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// minWrapWidth is the narrowest column into which text is wrapped; text
+// that would have to be squeezed into less space isn't wrapped at all.
+const minWrapWidth = 20
+
+// terminalWidth returns the width, in columns, of the terminal to which w
+// writes or, if w isn't a terminal or its size can't be determined, the
+// width given by the COLUMNS environment variable. It returns 0 if neither
+// is known.
+func terminalWidth(w io.Writer) int {
+	width, _ := terminalSize(w)
+	return sizeOrEnv(width, "COLUMNS")
+}
+
+// terminalHeight is like terminalWidth, but returns the number of lines of
+// the terminal, or else the LINES environment variable.
+func terminalHeight(w io.Writer) int {
+	_, height := terminalSize(w)
+	return sizeOrEnv(height, "LINES")
+}
+
+func terminalSize(w io.Writer) (width, height int) {
+	if f, ok := underlyingWriter(w).(*os.File); ok {
+		return fileTerminalSize(f)
+	}
+	return 0, 0
+}
+
+// sizeOrEnv returns n if it is positive, or else the positive integer in the
+// environment variable env, or else 0.
+func sizeOrEnv(n int, env string) int {
+	if n > 0 {
+		return n
+	}
+	if n, err := strconv.Atoi(os.Getenv(env)); err == nil && n > 0 {
+		return n
+	}
+	return 0
+}
+
+// wrapText breaks s into lines of at most width columns at spaces. A word
+// longer than width gets a line of its own.
+func wrapText(s string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(s) {
+		switch {
+		case line == "":
+			line = word
+		case displayWidth(line)+1+displayWidth(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package subcmd
+
+import "os"
+
+// fileTerminalSize returns zeros: the size of a terminal isn't detected on
+// this platform, so terminalWidth and terminalHeight rely on the COLUMNS and
+// LINES environment variables.
+func fileTerminalSize(f *os.File) (width, height int) { return 0, 0 }
@@ -0,0 +1,37 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package subcmd
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// fileTerminalSize returns the width and height of the terminal f, or zeros
+// if f isn't a terminal.
+func fileTerminalSize(f *os.File) (width, height int) {
+	ws, ok := getWinsize(f)
+	if !ok {
+		return 0, 0
+	}
+	return int(ws.col), int(ws.row)
+}
+
+// fileIsTerminal reports whether f is a terminal.
+func fileIsTerminal(f *os.File) bool {
+	_, ok := getWinsize(f)
+	return ok
+}
+
+type winsize struct {
+	row, col, xpixel, ypixel uint16
+}
+
+// getWinsize gets the size of the terminal f with the TIOCGWINSZ ioctl,
+// which fails if f isn't a terminal.
+func getWinsize(f *os.File) (ws winsize, ok bool) {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	return ws, errno == 0
+}
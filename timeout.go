@@ -0,0 +1,93 @@
+package subcmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// timeoutExitCode is the exit status of a program whose deadline passes, as
+// for the timeout(1) utility.
+const timeoutExitCode = 124
+
+// errTimedOut is wrapped by the error reported when a command times out.
+var errTimedOut = errors.New("timed out")
+
+// startTimeout applies the time limit of cmd, as given by commandTimeout, to
+// running it: it returns a context derived from ctx with the corresponding
+// deadline, which runTimed observes. The returned function cancels the
+// timeout.
+func (r *Runner) startTimeout(ctx context.Context, cmd *Command) (context.Context, func(), error) {
+	d, _, err := r.commandTimeout(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	if d == 0 {
+		return ctx, func() {}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return ctx, cancel, nil
+}
+
+// runTimed runs cmd as runCommand does. A command without a DoContext
+// function can't be told that its time is up, so if it has a time limit and
+// ctx ends first, runTimed stops waiting for it and returns ctx.Err(),
+// leaving the command running in the background.
+func (r *Runner) runTimed(ctx context.Context, cmd *Command, args []string) error {
+	if d, _, _ := r.commandTimeout(cmd); d == 0 || cmd.DoContext != nil {
+		return r.runCommand(ctx, cmd, args)
+	}
+	done := make(chan error, 1)
+	go func() { done <- r.runCommand(ctx, cmd, args) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// timeout returns the duration set by the environment variable named by
+// r.TimeoutEnv, or 0 if there is none.
+func (r *Runner) timeout() (time.Duration, error) {
+	if r.TimeoutEnv == "" {
+		return 0, nil
+	}
+	s := os.Getenv(r.TimeoutEnv)
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, r.errorf("invalid %s %s: must be a positive duration such as 30s", r.TimeoutEnv, quoteArg(s))
+	}
+	return d, nil
+}
+
+// commandTimeout returns the time limit for running cmd, which is the shorter
+// of cmd.Timeout and the duration set by r.TimeoutEnv (ignoring either one if
+// it is unset), or 0 if there is no limit. It also reports whether the limit
+// comes from r.TimeoutEnv.
+func (r *Runner) commandTimeout(cmd *Command) (d time.Duration, fromEnv bool, err error) {
+	d, err = r.timeout()
+	if err != nil {
+		return 0, false, err
+	}
+	if cmd.Timeout > 0 && (d == 0 || cmd.Timeout < d) {
+		return cmd.Timeout, false, nil
+	}
+	return d, d > 0, nil
+}
+
+// timeoutError returns the error reported when a command exceeds its time
+// limit d, which may have been set by r.TimeoutEnv. Its ExitCode is 124.
+func (r *Runner) timeoutError(d time.Duration, fromEnv bool) error {
+	var err error
+	if fromEnv {
+		err = r.errorf("%w after %s (set by %s)", errTimedOut, d, r.TimeoutEnv)
+	} else {
+		err = r.errorf("%w after %s", errTimedOut, d)
+	}
+	return WithExitCode(err, timeoutExitCode)
+}
@@ -0,0 +1,65 @@
+package subcmd_test
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cespare/subcmd"
+	"github.com/cespare/subcmd/subcmdtest"
+)
+
+func TestTimeoutEnv(t *testing.T) {
+	t.Setenv("PROG_TIMEOUT", "10ms")
+	block := make(chan struct{})
+	defer close(block)
+	cmds := []subcmd.Command{{Name: "slow", Do: func([]string) { <-block }}}
+
+	r := subcmd.New("prog", cmds, flag.ContinueOnError)
+	r.TimeoutEnv = "PROG_TIMEOUT"
+	r.Exit = func(code int) { t.Fatalf("Exit(%d) called under ContinueOnError", code) }
+	err := r.Run([]string{"slow"})
+	var ec subcmd.ExitCoder
+	if !errors.As(err, &ec) || ec.ExitCode() != 124 {
+		t.Errorf("ContinueOnError: got error %v; want a timeout with exit code 124", err)
+	}
+
+	r = subcmd.New("prog", cmds, flag.ExitOnError)
+	r.TimeoutEnv = "PROG_TIMEOUT"
+	res := subcmdtest.Run(r, "slow")
+	if !res.Exited || res.ExitCode != 124 || !strings.Contains(res.Stderr, "timed out after 10ms") {
+		t.Errorf("ExitOnError: got %+v; want a timeout with exit code 124", res)
+	}
+}
+
+func TestTimeoutFinishes(t *testing.T) {
+	t.Setenv("PROG_TIMEOUT", "1m")
+	cmds := []subcmd.Command{{
+		Name:  "fail",
+		DoErr: func([]string) error { return errors.New("boom") },
+	}}
+	r := subcmd.New("prog", cmds, flag.ContinueOnError)
+	r.TimeoutEnv = "PROG_TIMEOUT"
+	if err := r.Run([]string{"fail"}); err == nil || err.Error() != "prog fail: boom" {
+		t.Errorf("got error %v; want prog fail: boom", err)
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	cmds := []subcmd.Command{{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Do:      func([]string) { <-block },
+	}}
+	r := subcmd.New("prog", cmds, flag.ContinueOnError)
+	r.Exit = func(code int) { t.Fatalf("Exit(%d) called under ContinueOnError", code) }
+	err := r.Run([]string{"slow"})
+	var ec subcmd.ExitCoder
+	if !errors.As(err, &ec) || ec.ExitCode() != 124 || err.Error() != "prog: timed out after 10ms" {
+		t.Errorf("got error %v; want a timeout with exit code 124", err)
+	}
+}
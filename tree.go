@@ -0,0 +1,98 @@
+package subcmd
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+)
+
+// A CommandInfo describes a command, or the program itself, for tools such
+// as documentation generators, launchers, and completion engines. It is
+// produced by Runner.CommandTree.
+type CommandInfo struct {
+	Name        string        `json:"name"`
+	Aliases     []string      `json:"aliases,omitempty"`
+	Description string        `json:"description,omitempty"`
+	ArgsUsage   string        `json:"argsUsage,omitempty"`
+	Stability   string        `json:"stability,omitempty"`
+	Hidden      bool          `json:"hidden,omitempty"`
+	Deprecated  string        `json:"deprecated,omitempty"`
+	Flags       []FlagInfo    `json:"flags,omitempty"`
+	Subcommands []CommandInfo `json:"subcommands,omitempty"`
+}
+
+// A FlagInfo describes a flag in a CommandInfo.
+type FlagInfo struct {
+	Name     string `json:"name"`
+	Usage    string `json:"usage,omitempty"`
+	Default  string `json:"default,omitempty"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// CommandTree describes the program and all of its commands, including
+// hidden commands, built-in commands, and Subcommands. The Name of the
+// returned CommandInfo is the runner's name and its Flags are the global
+// flags.
+func (r *Runner) CommandTree() CommandInfo {
+	info := CommandInfo{
+		Name:  r.name,
+		Flags: flagInfos(r.Flags),
+	}
+	cmds := append([]Command(nil), r.cmds...)
+	for _, cmd := range r.builtins() {
+		if _, ok := r.lookupDefined(cmd.Name); !ok {
+			cmds = append(cmds, cmd)
+		}
+	}
+	for i := range cmds {
+		info.Subcommands = append(info.Subcommands, r.commandInfo(&cmds[i]))
+	}
+	return info
+}
+
+// WriteCommandTree writes the CommandTree of r to w as JSON.
+func (r *Runner) WriteCommandTree(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.CommandTree())
+}
+
+func (r *Runner) commandInfo(cmd *Command) CommandInfo {
+	info := CommandInfo{
+		Name:        cmd.Name,
+		Aliases:     cmd.Aliases,
+		Description: cmd.description(),
+		ArgsUsage:   cmd.argsUsage(),
+		Hidden:      cmd.Hidden,
+		Deprecated:  cmd.Deprecated,
+		Flags:       flagInfos(cmd.Flags),
+	}
+	for i, f := range info.Flags {
+		info.Flags[i].Required = contains(cmd.RequiredFlags, f.Name)
+	}
+	if cmd.Stability != Stable {
+		info.Stability = cmd.Stability.String()
+	}
+	if len(cmd.Subcommands) > 0 {
+		child := r.child(cmd)
+		for i := range child.cmds {
+			info.Subcommands = append(info.Subcommands, child.commandInfo(&child.cmds[i]))
+		}
+	}
+	return info
+}
+
+func flagInfos(fs *flag.FlagSet) []FlagInfo {
+	if fs == nil {
+		return nil
+	}
+	var infos []FlagInfo
+	fs.VisitAll(func(f *flag.Flag) {
+		infos = append(infos, FlagInfo{
+			Name:    f.Name,
+			Usage:   f.Usage,
+			Default: f.DefValue,
+		})
+	})
+	return infos
+}
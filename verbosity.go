@@ -0,0 +1,91 @@
+package subcmd
+
+import "strconv"
+
+// A Verbosity is how much the program reports about what it is doing, as
+// set by the flags that VerbosityFlags defines.
+type Verbosity int
+
+// These are the standard verbosities. Each -v flag beyond the first
+// increases the verbosity further, so a program may offer several levels of
+// detail by comparing the verbosity against values above Verbose.
+const (
+	Quiet   Verbosity = -1 // report only errors
+	Normal  Verbosity = 0  // report as usual
+	Verbose Verbosity = 1  // report in more detail
+)
+
+// VerbosityFlags defines the global flags -v and -verbose, which increase
+// r.Verbosity (and may be repeated, as in "prog -v -v build"), and -q and
+// -quiet, which set it to Quiet, on r.Flags. Whichever of the flags comes
+// last takes precedence. Commands consult r.Verbosity to decide what to
+// report, so that all of the program's commands treat the flags in the same
+// way:
+//
+//	if r.Verbosity >= subcmd.Verbose {
+//		fmt.Fprintf(r.Stderr(), "fetching %s\n", url)
+//	}
+func (r *Runner) VerbosityFlags() {
+	const (
+		verboseUsage = "report in more detail (may be repeated)"
+		quietUsage   = "report only errors"
+	)
+	r.Flags.Var(&verboseFlag{&r.Verbosity}, "v", verboseUsage)
+	r.Flags.Var(&verboseFlag{&r.Verbosity}, "verbose", verboseUsage)
+	r.Flags.Var(&quietFlag{&r.Verbosity}, "q", quietUsage)
+	r.Flags.Var(&quietFlag{&r.Verbosity}, "quiet", quietUsage)
+}
+
+// verboseFlag is the flag.Value of -v, a boolean flag that increases the
+// verbosity each time it is given.
+type verboseFlag struct{ v *Verbosity }
+
+func (f *verboseFlag) IsBoolFlag() bool { return true }
+
+func (f *verboseFlag) String() string {
+	if f.v == nil {
+		return "false"
+	}
+	return strconv.FormatBool(*f.v > Normal)
+}
+
+func (f *verboseFlag) Set(s string) error {
+	on, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !on:
+		*f.v = Normal
+	case *f.v < Normal:
+		*f.v = Verbose
+	default:
+		*f.v++
+	}
+	return nil
+}
+
+// quietFlag is the flag.Value of -q.
+type quietFlag struct{ v *Verbosity }
+
+func (f *quietFlag) IsBoolFlag() bool { return true }
+
+func (f *quietFlag) String() string {
+	if f.v == nil {
+		return "false"
+	}
+	return strconv.FormatBool(*f.v == Quiet)
+}
+
+func (f *quietFlag) Set(s string) error {
+	on, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if on {
+		*f.v = Quiet
+	} else if *f.v == Quiet {
+		*f.v = Normal
+	}
+	return nil
+}
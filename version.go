@@ -0,0 +1,102 @@
+package subcmd
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compareVersions compares two version strings of the form
+// "v1.2.3-pre", returning -1, 0, or +1 as a is earlier than, the same as, or
+// later than b. The leading "v" is optional and missing components count as
+// zero. A version with a pre-release suffix is earlier than the same version
+// without one, and pre-release suffixes are ordered as by comparePrerelease.
+// Release components that are not numbers are compared lexically.
+func compareVersions(a, b string) int {
+	a, apre := splitVersion(a)
+	b, bpre := splitVersion(b)
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for len(as) < len(bs) {
+		as = append(as, "0")
+	}
+	for len(bs) < len(as) {
+		bs = append(bs, "0")
+	}
+	for i := range as {
+		if c := compareVersionParts(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case apre == bpre:
+		return 0
+	case apre == "":
+		return 1
+	case bpre == "":
+		return -1
+	}
+	return comparePrerelease(apre, bpre)
+}
+
+// comparePrerelease compares the pre-release parts of two versions, such as
+// "rc.9" and "rc.10", as SemVer does: their dot-separated identifiers are
+// compared in turn, numerically if both are numbers, and a number comes
+// before an identifier that isn't. If all of the identifiers of one are
+// the same as the first ones of the other, the shorter one comes first.
+func comparePrerelease(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		_, aerr := strconv.Atoi(as[i])
+		_, berr := strconv.Atoi(bs[i])
+		var c int
+		switch {
+		case aerr == nil && berr == nil:
+			c = compareVersionParts(as[i], bs[i])
+		case aerr == nil:
+			return -1
+		case berr == nil:
+			return 1
+		default:
+			c = strings.Compare(as[i], bs[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// splitVersion trims the optional "v" prefix and "+build" suffix from v and
+// splits it into its release and pre-release parts.
+func splitVersion(v string) (release, pre string) {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		return v[:i], v[i+1:]
+	}
+	return v, ""
+}
+
+func compareVersionParts(a, b string) int {
+	an, aerr := strconv.Atoi(a)
+	bn, berr := strconv.Atoi(b)
+	if aerr == nil && berr == nil {
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}